@@ -0,0 +1,427 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package gha
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/ericcornelissen/go-gha-models/expr"
+)
+
+// availability is what the expressions at a location in a workflow or manifest
+// can use.
+type availability struct {
+	// contexts are the names of the available contexts, e.g. "github".
+	contexts []string
+
+	// functions are the names of the available special functions, see
+	// specialFunctions. Other functions are available everywhere.
+	functions []string
+
+	// steps are the ids of the steps whose results are available through the
+	// `steps` context, e.g. the steps before a step.
+	steps []string
+
+	// needs are the ids of the jobs whose results are available through the
+	// `needs` context, i.e. a job's `needs:`.
+	needs []string
+}
+
+// with returns a copy of a with the given contexts added.
+func (a availability) with(contexts ...string) availability {
+	a.contexts = slices.Concat(a.contexts, contexts)
+	return a
+}
+
+// without returns a copy of a with the given contexts removed.
+func (a availability) without(contexts ...string) availability {
+	a.contexts = slices.DeleteFunc(slices.Clone(a.contexts), func(name string) bool {
+		return slices.Contains(contexts, name)
+	})
+
+	return a
+}
+
+// withFunctions returns a copy of a with the given special functions added.
+func (a availability) withFunctions(functions ...string) availability {
+	a.functions = slices.Concat(a.functions, functions)
+	return a
+}
+
+// withSteps returns a copy of a with the given steps available. The `steps`
+// context is made available if it is not already.
+func (a availability) withSteps(ids ...string) availability {
+	if !slices.Contains(a.contexts, "steps") {
+		a = a.with("steps")
+	}
+
+	a.steps = slices.Concat(a.steps, ids)
+	return a
+}
+
+// specialFunctions are the functions that are only available in some locations.
+var specialFunctions = []string{"always", "cancelled", "failure", "hashFiles", "success"}
+
+// statusFunctions are the status check functions, which are only available in
+// conditions.
+var statusFunctions = []string{"always", "cancelled", "failure", "success"}
+
+// The availability of contexts follows GitHub's documentation, see:
+// https://docs.github.com/en/actions/learn-github-actions/contexts#context-availability
+var (
+	workflowAvailability = availability{contexts: []string{"github", "inputs", "vars"}}
+	jobAvailability      = workflowAvailability.with("needs", "strategy", "matrix")
+	stepAvailability     = jobAvailability.with("job", "runner", "env", "secrets").withFunctions("hashFiles")
+
+	manifestAvailability = availability{
+		contexts: []string{"github", "inputs", "job", "runner", "env", "strategy", "matrix"},
+	}
+)
+
+// CheckContexts reports the expressions in the workflow that use a context or
+// function that is not available where the expression is used, e.g. `secrets`
+// in a job's `if:` or `matrix` in the workflow's `concurrency:`. The `steps`
+// context is only available in a step if an earlier step has an `id:`.
+//
+// Expressions that cannot be parsed are ignored.
+func (w *Workflow) CheckContexts() Errors {
+	return w.check(checkContexts)
+}
+
+// check analyzes every expression in the workflow with analyze.
+func (w *Workflow) check(analyze analyzer) Errors {
+	var errs Errors
+
+	c := checker{position: w.Position, positions: w.Positions, workflow: w, analyze: analyze, errs: &errs}
+	c.check("run-name", w.RunName, workflowAvailability)
+	c.checkConcurrency("concurrency", &w.Concurrency, workflowAvailability)
+	c.checkMap("env", w.Env, workflowAvailability.with("secrets"))
+
+	if call := w.On.WorkflowCall; call != nil {
+		for _, name := range slices.Sorted(maps.Keys(call.Inputs)) {
+			key := "on.workflow_call.inputs." + name + ".default"
+			c.check(key, call.Inputs[name].Default, workflowAvailability)
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(call.Outputs)) {
+			key := "on.workflow_call.outputs." + name + ".value"
+			c.check(key, call.Outputs[name].Value, workflowAvailability.with("jobs"))
+		}
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(w.Jobs)) {
+		job := w.Jobs[id]
+		job.check(c.child("jobs."+id, job.Position, job.Positions))
+	}
+
+	sortErrors(errs)
+	return errs
+}
+
+func (j *Job) check(c *checker) {
+	ids := stepIds(j.Steps)
+
+	job := jobAvailability
+	job.needs = j.Needs
+
+	c.check("name", j.Name, job)
+	c.check("environment.name", j.Environment.Name, job)
+	c.check("environment.url", j.Environment.Url, job.with("job", "runner", "env").withSteps(ids...))
+	c.checkCondition("if", j.If, job.without("strategy", "matrix").withFunctions(statusFunctions...))
+	c.checkConcurrency("concurrency", &j.Concurrency, job)
+	c.check("defaults.run.shell", j.Defaults.Run.Shell, job.with("env"))
+	c.check("defaults.run.working-directory", j.Defaults.Run.WorkingDirectory, job.with("env"))
+	c.checkMatrix("strategy.matrix", &j.Strategy.Matrix, job.without("strategy", "matrix"))
+	c.checkService("container", (*Service)(&j.Container), job)
+
+	for _, id := range slices.Sorted(maps.Keys(j.Services)) {
+		service := j.Services[id]
+		c.checkService("services."+id, &service, job)
+	}
+
+	c.checkMap("outputs", j.Outputs, job.with("job", "runner", "env", "secrets").withSteps(ids...))
+	c.checkMap("env", j.Env, job.with("secrets"))
+	c.check("runs-on.group", j.RunsOn.Group, job)
+
+	for i, label := range j.RunsOn.Labels {
+		c.check(fmt.Sprintf("runs-on.labels[%d]", i), label, job)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(j.With)) {
+		c.check("with."+name, j.With[name].Raw, job)
+	}
+
+	c.checkMap("secrets", j.Secrets.Values, job.with("secrets"))
+
+	steps := stepAvailability
+	steps.needs = j.Needs
+	c.checkSteps("steps", j.Steps, steps)
+}
+
+// CheckContexts reports the expressions in the manifest that use a context or
+// function that is not available where the expression is used, e.g. `secrets`
+// in a composite step. The `steps` context is only available in a step if an
+// earlier step has an `id:`.
+//
+// Expressions that cannot be parsed are ignored.
+func (m *Manifest) CheckContexts() Errors {
+	return m.check(checkContexts)
+}
+
+// check analyzes every expression in the manifest with analyze.
+func (m *Manifest) check(analyze analyzer) Errors {
+	var errs Errors
+
+	c := checker{position: m.Position, positions: m.Positions, analyze: analyze, errs: &errs}
+
+	outputs := manifestAvailability.withSteps(stepIds(m.Runs.Steps)...)
+	for _, name := range slices.Sorted(maps.Keys(m.Outputs)) {
+		c.check("outputs."+name+".value", m.Outputs[name].Value, outputs)
+	}
+
+	for i, arg := range m.Runs.Args {
+		c.check(fmt.Sprintf("runs.args[%d]", i), arg, manifestAvailability)
+	}
+
+	c.checkMap("runs.env", m.Runs.Env, manifestAvailability)
+	c.check("runs.pre-entrypoint", m.Runs.PreEntrypoint, manifestAvailability)
+	c.check("runs.entrypoint", m.Runs.Entrypoint, manifestAvailability)
+	c.check("runs.post-entrypoint", m.Runs.PostEntrypoint, manifestAvailability)
+	c.checkCondition("runs.pre-if", m.Runs.PreIf, manifestAvailability.withFunctions(statusFunctions...))
+	c.checkCondition("runs.post-if", m.Runs.PostIf, manifestAvailability.withFunctions(statusFunctions...))
+
+	c.checkSteps("runs.steps", m.Runs.Steps, manifestAvailability.withFunctions("hashFiles"))
+
+	sortErrors(errs)
+	return errs
+}
+
+// stepIds returns the ids of the steps that have one.
+func stepIds(steps []Step) []string {
+	var ids []string
+	for _, step := range steps {
+		if step.Id != "" {
+			ids = append(ids, step.Id)
+		}
+	}
+
+	return ids
+}
+
+func (s *Step) check(c *checker, a availability) {
+	c.check("name", s.Name, a)
+	c.checkCondition("if", s.If, a.without("secrets").withFunctions(statusFunctions...))
+	c.check("run", s.Run, a)
+	c.check("working-directory", s.WorkingDirectory, a)
+
+	for _, name := range slices.Sorted(maps.Keys(s.With)) {
+		c.check("with."+name, s.With[name].Raw, a)
+	}
+
+	c.checkMap("env", s.Env, a)
+}
+
+// checkContexts reports the contexts and functions used in n that are not
+// available according to a.
+func checkContexts(c *checker, key string, n expr.Node, a availability) {
+	expr.Walk(n, func(n expr.Node) bool {
+		switch n := n.(type) {
+		case *expr.Ident:
+			if !containsFold(a.contexts, n.Name) {
+				c.report(key, "context %q is not available", n.Name)
+			}
+		case *expr.Call:
+			if containsFold(specialFunctions, n.Name) && !containsFold(a.functions, n.Name) {
+				c.report(key, "function %q is not available", n.Name)
+			}
+		}
+
+		return true
+	})
+}
+
+// analyzer reports the problems with the expression n, found at key, given
+// what is available according to a.
+type analyzer func(c *checker, key string, n expr.Node, a availability)
+
+// checker walks the expressions in one object of a workflow or manifest, e.g.
+// a job, and collects the problems found by its analyzer.
+type checker struct {
+	// path is the path to the object, e.g. "jobs.build". It is empty for the
+	// root object.
+	path string
+
+	// position and positions are the position of the object and its keys.
+	position  Position
+	positions Positions
+
+	// workflow is the workflow that is checked, if any.
+	workflow *Workflow
+
+	analyze analyzer
+
+	// errs are the problems found so far, shared with the child checkers.
+	errs *Errors
+
+	// reported are the messages reported for the current value.
+	reported map[string]bool
+}
+
+// child returns a checker for the object at path.
+func (c *checker) child(path string, position Position, positions Positions) *checker {
+	if c.path != "" {
+		path = c.path + "." + path
+	}
+
+	return &checker{
+		path:      path,
+		position:  position,
+		positions: positions,
+		workflow:  c.workflow,
+		analyze:   c.analyze,
+		errs:      c.errs,
+	}
+}
+
+// check analyzes the expressions embedded in value. The key is the path to
+// value in the object.
+func (c *checker) check(key, value string, a availability) {
+	exprs, _ := expr.Extract(value)
+
+	c.reported = make(map[string]bool)
+	for _, e := range exprs {
+		c.analyze(c, key, e.Node, a)
+	}
+}
+
+// checkCondition is like check for an `if:` value, which is an expression even
+// if it is not wrapped in `${{ }}`.
+func (c *checker) checkCondition(key, cond string, a availability) {
+	if cond != "" && !strings.Contains(cond, "${{") {
+		cond = "${{ " + cond + " }}"
+	}
+
+	c.check(key, cond, a)
+}
+
+func (c *checker) checkMap(key string, m map[string]string, a availability) {
+	for _, name := range slices.Sorted(maps.Keys(m)) {
+		c.check(key+"."+name, m[name], a)
+	}
+}
+
+func (c *checker) checkConcurrency(key string, concurrency *Concurrency, a availability) {
+	c.check(key+".group", concurrency.Group, a)
+	c.check(key+".cancel-in-progress", concurrency.CancelInProgress, a)
+}
+
+func (c *checker) checkMatrix(key string, matrix *Matrix, a availability) {
+	c.check(key, matrix.Expression, a)
+
+	for _, axis := range matrix.Axes {
+		c.check(key+"."+axis.Name, axis.Expression, a)
+		c.checkValue(key+"."+axis.Name, axis.Values, a)
+	}
+
+	c.check(key+".include", matrix.IncludeExpression, a)
+	for i, entry := range matrix.Include {
+		c.checkValue(fmt.Sprintf("%s.include[%d]", key, i), entry, a)
+	}
+
+	c.check(key+".exclude", matrix.ExcludeExpression, a)
+	for i, entry := range matrix.Exclude {
+		c.checkValue(fmt.Sprintf("%s.exclude[%d]", key, i), entry, a)
+	}
+}
+
+// checkValue checks the strings in v, which is a value of a matrix.
+func (c *checker) checkValue(key string, v any, a availability) {
+	switch v := v.(type) {
+	case string:
+		c.check(key, v, a)
+	case []any:
+		for i, item := range v {
+			c.checkValue(fmt.Sprintf("%s[%d]", key, i), item, a)
+		}
+	case map[string]any:
+		for _, name := range slices.Sorted(maps.Keys(v)) {
+			c.checkValue(key+"."+name, v[name], a)
+		}
+	}
+}
+
+func (c *checker) checkService(key string, service *Service, a availability) {
+	c.check(key+".image", service.Image, a)
+	c.check(key+".credentials.username", service.Credentials.Username, a.with("env", "secrets"))
+	c.check(key+".credentials.password", service.Credentials.Password, a.with("env", "secrets"))
+	c.checkMap(key+".env", service.Env, a.with("job", "runner", "env", "secrets"))
+
+	for i, port := range service.Ports {
+		c.check(fmt.Sprintf("%s.ports[%d]", key, i), port, a)
+	}
+
+	for i, volume := range service.Volumes {
+		c.check(fmt.Sprintf("%s.volumes[%d]", key, i), volume, a)
+	}
+
+	c.check(key+".options", service.Options, a)
+}
+
+// checkSteps checks the steps at key given what is available to all of them.
+// The results of a step with an id are available to the steps after it.
+func (c *checker) checkSteps(key string, steps []Step, a availability) {
+	for i, step := range steps {
+		step.check(c.child(fmt.Sprintf("%s[%d]", key, i), step.Position, step.Positions), a)
+		if step.Id != "" {
+			a = a.withSteps(step.Id)
+		}
+	}
+}
+
+// report adds a problem with the value at key, unless it was already reported
+// for the value. It is reported at the position of key or, if that is not
+// known, its closest parent.
+func (c *checker) report(key, format string, a ...any) {
+	msg := fmt.Sprintf(format, a...)
+	if c.reported[msg] {
+		return
+	}
+
+	c.reported[msg] = true
+
+	pos := c.position
+	for k := key; k != ""; {
+		if p, ok := c.positions[k]; ok {
+			pos = p
+			break
+		}
+
+		i := strings.LastIndexAny(k, ".[")
+		if i == -1 {
+			break
+		}
+
+		k = k[:i]
+	}
+
+	path := key
+	if c.path != "" {
+		path = c.path + "." + key
+	}
+
+	*c.errs = append(*c.errs, &Error{
+		Path:    path,
+		Line:    pos.Line,
+		Column:  pos.Column,
+		Message: msg,
+	})
+}
+
+func containsFold(names []string, name string) bool {
+	return slices.ContainsFunc(names, func(s string) bool {
+		return strings.EqualFold(s, name)
+	})
+}
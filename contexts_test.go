@@ -0,0 +1,330 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package gha
+
+import (
+	"testing"
+)
+
+func TestWorkflowCheckContexts(t *testing.T) {
+	type TestCase struct {
+		yaml string
+		want Errors
+	}
+
+	testCases := map[string]TestCase{
+		"Available everywhere": {
+			yaml: `name: Example
+run-name: Deploy ${{ inputs.target }} by ${{ github.actor }}
+on:
+  workflow_call:
+    inputs:
+      target:
+        type: string
+        default: ${{ vars.DEFAULT_TARGET }}
+    outputs:
+      url:
+        value: ${{ jobs.deploy.outputs.url }}
+concurrency: ${{ github.workflow }}-${{ github.ref }}
+env:
+  TOKEN: ${{ secrets.TOKEN }}
+jobs:
+  deploy:
+    name: Deploy to ${{ matrix.target }}
+    if: github.event_name == 'push' && needs.build.result == 'success' || failure()
+    needs: [build]
+    runs-on: ${{ matrix.os }}
+    environment:
+      name: ${{ matrix.target }}
+      url: ${{ steps.deploy.outputs.url }}
+    strategy:
+      matrix: ${{ fromJSON(needs.build.outputs.matrix) }}
+    outputs:
+      url: ${{ steps.deploy.outputs.url }}
+    env:
+      TOKEN: ${{ secrets.TOKEN }}
+    steps:
+      - id: deploy
+        if: ${{ always() && runner.os == 'Linux' }}
+        run: ./deploy.sh ${{ env.TOKEN }} ${{ hashFiles('**/go.sum') }}
+      - name: Report
+        run: echo ${{ steps.deploy.outputs.url }}
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make
+`,
+			want: nil,
+		},
+		"Case insensitive": {
+			yaml: `on: push
+jobs:
+  build:
+    if: ${{ GitHub.event_name == 'push' && Success() }}
+    runs-on: ubuntu-latest
+    steps:
+      - run: make
+`,
+			want: nil,
+		},
+		"Workflow": {
+			yaml: `run-name: ${{ secrets.TOKEN }}
+on: push
+concurrency:
+  group: ${{ matrix.os }}
+env:
+  OS: ${{ runner.os }}
+jobs: {}
+`,
+			want: Errors{
+				{
+					Path:    "run-name",
+					Line:    1,
+					Column:  1,
+					Message: `context "secrets" is not available`,
+				},
+				{
+					Path:    "concurrency.group",
+					Line:    4,
+					Column:  3,
+					Message: `context "matrix" is not available`,
+				},
+				{
+					Path:    "env.OS",
+					Line:    6,
+					Column:  3,
+					Message: `context "runner" is not available`,
+				},
+			},
+		},
+		"Job": {
+			yaml: `on: push
+jobs:
+  build:
+    if: secrets.TOKEN != '' && hashFiles('go.sum') != ''
+    runs-on: ${{ env.RUNNER }}
+    strategy:
+      matrix:
+        os: [ubuntu-latest, "${{ matrix.os }}"]
+    container:
+      image: node:${{ secrets.VERSION }}
+      env:
+        TOKEN: ${{ secrets.TOKEN }}
+    with:
+      token: ${{ secrets.TOKEN }}
+    env:
+      STATUS: ${{ success() }}
+`,
+			want: Errors{
+				{
+					Path:    "jobs.build.if",
+					Line:    4,
+					Column:  5,
+					Message: `context "secrets" is not available`,
+				},
+				{
+					Path:    "jobs.build.if",
+					Line:    4,
+					Column:  5,
+					Message: `function "hashFiles" is not available`,
+				},
+				{
+					Path:    "jobs.build.runs-on.labels[0]",
+					Line:    5,
+					Column:  5,
+					Message: `context "env" is not available`,
+				},
+				{
+					Path:    "jobs.build.strategy.matrix.os[1]",
+					Line:    7,
+					Column:  7,
+					Message: `context "matrix" is not available`,
+				},
+				{
+					Path:    "jobs.build.container.image",
+					Line:    10,
+					Column:  7,
+					Message: `context "secrets" is not available`,
+				},
+				{
+					Path:    "jobs.build.with.token",
+					Line:    14,
+					Column:  7,
+					Message: `context "secrets" is not available`,
+				},
+				{
+					Path:    "jobs.build.env.STATUS",
+					Line:    16,
+					Column:  7,
+					Message: `function "success" is not available`,
+				},
+			},
+		},
+		"Steps": {
+			yaml: `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo ${{ steps.foo.outputs.bar }}
+      - if: ${{ secrets.TOKEN }}
+        run: echo ${{ failure() }}
+      - id: foo
+        run: echo ${{ steps.bar.outputs.baz }}
+      - run: echo ${{ steps.foo.outputs.bar }}
+`,
+			want: Errors{
+				{
+					Path:    "jobs.build.steps[0].run",
+					Line:    6,
+					Column:  9,
+					Message: `context "steps" is not available`,
+				},
+				{
+					Path:    "jobs.build.steps[1].if",
+					Line:    7,
+					Column:  9,
+					Message: `context "secrets" is not available`,
+				},
+				{
+					Path:    "jobs.build.steps[1].run",
+					Line:    8,
+					Column:  9,
+					Message: `function "failure" is not available`,
+				},
+				{
+					Path:    "jobs.build.steps[2].run",
+					Line:    10,
+					Column:  9,
+					Message: `context "steps" is not available`,
+				},
+			},
+		},
+		"Reported once per value": {
+			yaml: `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo ${{ foo.bar }} ${{ foo.baz }}
+`,
+			want: Errors{
+				{
+					Path:    "jobs.build.steps[0].run",
+					Line:    6,
+					Column:  9,
+					Message: `context "foo" is not available`,
+				},
+			},
+		},
+		"Invalid expression": {
+			yaml: `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo ${{ secrets.TOKEN == }}
+`,
+			want: nil,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			workflow, err := ParseWorkflow([]byte(tt.yaml))
+			if err != nil {
+				t.Fatalf("Want no error, got %#v", err)
+			}
+
+			checkErrors(t, workflow.CheckContexts(), tt.want)
+		})
+	}
+}
+
+func TestManifestCheckContexts(t *testing.T) {
+	type TestCase struct {
+		yaml string
+		want Errors
+	}
+
+	testCases := map[string]TestCase{
+		"Composite": {
+			yaml: `name: Example
+description: An example action
+outputs:
+  version:
+    value: ${{ steps.version.outputs.version }}
+runs:
+  using: composite
+  steps:
+    - if: ${{ github.event_name == 'push' && !cancelled() }}
+      run: echo ${{ steps.version.outputs.version }}
+      shell: bash
+    - id: version
+      run: echo ${{ hashFiles('go.sum') }} ${{ secrets.TOKEN }}
+      shell: bash
+    - run: echo ${{ steps.version.outputs.version }} ${{ vars.FOO }}
+      shell: bash
+`,
+			want: Errors{
+				{
+					Path:    "runs.steps[0].run",
+					Line:    10,
+					Column:  7,
+					Message: `context "steps" is not available`,
+				},
+				{
+					Path:    "runs.steps[1].run",
+					Line:    13,
+					Column:  7,
+					Message: `context "secrets" is not available`,
+				},
+				{
+					Path:    "runs.steps[2].run",
+					Line:    15,
+					Column:  7,
+					Message: `context "vars" is not available`,
+				},
+			},
+		},
+		"Docker": {
+			yaml: `name: Example
+description: An example action
+runs:
+  using: docker
+  image: Dockerfile
+  args:
+    - ${{ inputs.foo }}
+    - ${{ steps.foo.outputs.bar }}
+  env:
+    FOO: ${{ hashFiles('go.sum') }}
+  post-if: ${{ always() }}
+`,
+			want: Errors{
+				{
+					Path:    "runs.args[1]",
+					Line:    6,
+					Column:  3,
+					Message: `context "steps" is not available`,
+				},
+				{
+					Path:    "runs.env.FOO",
+					Line:    9,
+					Column:  3,
+					Message: `function "hashFiles" is not available`,
+				},
+			},
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			manifest, err := ParseManifest([]byte(tt.yaml))
+			if err != nil {
+				t.Fatalf("Want no error, got %#v", err)
+			}
+
+			checkErrors(t, manifest.CheckContexts(), tt.want)
+		})
+	}
+}
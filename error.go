@@ -0,0 +1,271 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package gha
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Error is a problem found in a YAML document.
+type Error struct {
+	// Path is the path to the problematic value, e.g. "jobs.build.steps[2].uses".
+	// It is empty if the problem is not tied to a value.
+	Path string
+
+	// Line and Column are the position of the problem in the YAML document.
+	// They are 0 if the position is not known.
+	Line   int
+	Column int
+
+	// Message describes the problem.
+	Message string
+}
+
+func (e *Error) Error() string {
+	var location []string
+	if e.Path != "" {
+		location = append(location, e.Path)
+	}
+
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		location = append(location, fmt.Sprintf("(line %d, column %d)", e.Line, e.Column))
+	case e.Line > 0:
+		location = append(location, fmt.Sprintf("(line %d)", e.Line))
+	}
+
+	if len(location) == 0 {
+		return e.Message
+	}
+
+	return strings.Join(location, " ") + ": " + e.Message
+}
+
+// Errors is a list of problems found in a YAML document, in document order.
+type Errors []*Error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+func (e Errors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+
+	return errs
+}
+
+var (
+	// reDecodeError matches the messages of the YAML decoder's type errors as
+	// well as those created by newError.
+	reDecodeError = regexp.MustCompile(`^line (\d+)(?:, column (\d+))?: (.*)$`)
+
+	// reSyntaxError matches the message of the YAML parser's syntax errors.
+	reSyntaxError = regexp.MustCompile(`^yaml: (?:line (\d+): )?(.*)$`)
+
+	// reUnmarshalError matches the message of the YAML decoder's type errors,
+	// which name the tag and, for scalars, the value that cannot be decoded.
+	reUnmarshalError = regexp.MustCompile("^cannot unmarshal (!![a-z]+)(?: `(.*)`)? into ")
+)
+
+// newError returns an error for the value n. The error is collected by the
+// YAML decoder, so that decoding continues and all problems are reported.
+func newError(n *yaml.Node, format string, a ...any) error {
+	msg := fmt.Sprintf(format, a...)
+	return &yaml.TypeError{
+		Errors: []string{fmt.Sprintf("line %d, column %d: %s", n.Line, n.Column, msg)},
+	}
+}
+
+// joinErrors combines the errors collected by a custom unmarshaller into one.
+func joinErrors(errs []error) error {
+	var result yaml.TypeError
+	for _, err := range errs {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return err
+		}
+
+		result.Errors = append(result.Errors, typeErr.Errors...)
+	}
+
+	if len(result.Errors) == 0 {
+		return nil
+	}
+
+	return &result
+}
+
+// unmarshal decodes the YAML document data into v. Problems are reported as
+// [Errors]. If s is not nil, keys that are not allowed by s are reported too.
+func unmarshal(data []byte, v any, s *schema) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		matches := reSyntaxError.FindStringSubmatch(err.Error())
+		if matches == nil {
+			return Errors{{Message: err.Error()}}
+		}
+
+		line, _ := strconv.Atoi(matches[1])
+		return Errors{{Line: line, Message: matches[2]}}
+	}
+
+	if root.Kind == 0 {
+		return nil
+	}
+
+	var typeErr *yaml.TypeError
+	if err := root.Decode(v); err != nil && !errors.As(err, &typeErr) {
+		return err
+	}
+
+	var result Errors
+	if typeErr != nil {
+		for _, msg := range typeErr.Errors {
+			matches := reDecodeError.FindStringSubmatch(msg)
+			if matches == nil {
+				result = append(result, &Error{Message: msg})
+				continue
+			}
+
+			line, _ := strconv.Atoi(matches[1])
+			column, _ := strconv.Atoi(matches[2])
+			path, n := locate(&root, line, column, matches[3])
+			if n != nil {
+				column = n.Column
+			}
+
+			result = append(result, &Error{
+				Path:    path,
+				Line:    line,
+				Column:  column,
+				Message: matches[3],
+			})
+		}
+	}
+
+	result = append(result, s.check(root.Content[0], "")...)
+	if len(result) == 0 {
+		return nil
+	}
+
+	sortErrors(result)
+	return result
+}
+
+// sortErrors sorts errs in document order. Errors at the same position keep
+// their relative order.
+func sortErrors(errs Errors) {
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Line != errs[j].Line {
+			return errs[i].Line < errs[j].Line
+		}
+
+		return errs[i].Column < errs[j].Column
+	})
+}
+
+// locate finds the value at the given position in the YAML document root and
+// returns its path. If column is 0, as for the YAML decoder's own errors, the
+// outermost node on the line that matches the tag and value named in msg is
+// picked, looking into block collections as they start at their first entry.
+// If msg names neither, the last value of a mapping on the line is picked, as
+// it is the most specific one. Either falls back to any node on the line.
+func locate(root *yaml.Node, line, column int, msg string) (string, *yaml.Node) {
+	var (
+		candidatePath, fallbackPath string
+		candidate, fallback         *yaml.Node
+	)
+
+	tag := reUnmarshalError.FindStringSubmatch(msg)
+	matches := func(n *yaml.Node) bool {
+		switch tag[1] {
+		case "!!map":
+			return n.Kind == yaml.MappingNode
+		case "!!seq":
+			return n.Kind == yaml.SequenceNode
+		default:
+			// The decoder abbreviates values longer than 10 bytes to their first
+			// 7 bytes followed by "...".
+			abbreviated := len(tag[2]) == 10 && strings.HasSuffix(tag[2], "...")
+			return n.Kind == yaml.ScalarNode && n.ShortTag() == tag[1] &&
+				(n.Value == tag[2] || (abbreviated && strings.HasPrefix(n.Value, tag[2][:7])))
+		}
+	}
+
+	var walk func(n *yaml.Node, path string, isValue bool) bool
+	walk = func(n *yaml.Node, path string, isValue bool) bool {
+		found := false
+		if n.Line == line {
+			switch {
+			case column != 0 && n.Column == column:
+				candidatePath, candidate = path, n
+				return true
+			case column == 0 && tag != nil && matches(n):
+				candidatePath, candidate = path, n
+				if n.Kind == yaml.ScalarNode || n.Style&yaml.FlowStyle != 0 {
+					return true
+				}
+
+				// A block collection starts at its first entry, so an entry on
+				// the same line may be the node that was meant.
+				found = true
+			case column == 0 && tag == nil && isValue:
+				candidatePath, candidate = path, n
+			case fallback == nil:
+				fallbackPath, fallback = path, n
+			}
+		}
+
+		switch n.Kind {
+		case yaml.DocumentNode:
+			for _, c := range n.Content {
+				if walk(c, path, false) {
+					return true
+				}
+			}
+		case yaml.SequenceNode:
+			for i, c := range n.Content {
+				if walk(c, fmt.Sprintf("%s[%d]", path, i), false) {
+					return true
+				}
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				k, v := n.Content[i], n.Content[i+1]
+
+				p := k.Value
+				if path != "" {
+					p = path + "." + k.Value
+				}
+
+				if walk(v, p, true) {
+					return true
+				}
+			}
+		}
+
+		return found
+	}
+
+	walk(root, "", false)
+	if candidate != nil {
+		return candidatePath, candidate
+	}
+
+	return fallbackPath, fallback
+}
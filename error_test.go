@@ -0,0 +1,282 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package gha
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseWorkflowErrors(t *testing.T) {
+	type TestCase struct {
+		yaml string
+		want Errors
+	}
+
+	testCases := map[string]TestCase{
+		"Syntax error": {
+			yaml: `
+on: push
+ jobs: {}
+`,
+			want: Errors{
+				{Line: 3, Message: "mapping values are not allowed in this context"},
+			},
+		},
+		"Invalid document": {
+			yaml: `42`,
+			want: Errors{
+				{Line: 1, Column: 1, Message: "cannot unmarshal !!int `42` into gha.workflow"},
+			},
+		},
+		"Invalid value": {
+			yaml: `
+on: push
+jobs:
+  build:
+    steps:
+      - run: echo 'Hello world!'
+      - run: echo 'Hello world!'
+      - uses: actions/checkout@
+`,
+			want: Errors{
+				{Path: "jobs.build.steps[2].uses", Line: 8, Column: 15, Message: "invalid `uses` value (\"actions/checkout@\")"},
+			},
+		},
+		"Invalid value without column": {
+			yaml: `
+jobs:
+  build:
+    timeout-minutes: foo
+`,
+			want: Errors{
+				{Path: "jobs.build.timeout-minutes", Line: 4, Column: 22, Message: "cannot unmarshal !!str `foo` into int"},
+			},
+		},
+		"Invalid permissions": {
+			yaml: `
+permissions:
+  contents: admin
+  content: read
+`,
+			want: Errors{
+				{Path: "permissions.contents", Line: 3, Column: 13, Message: `invalid permissions.contents value "admin"`},
+			},
+		},
+		"Invalid mapping, flow": {
+			yaml: `
+on:
+  push:
+    branches: {a: b}
+`,
+			want: Errors{
+				{Path: "on.push.branches", Line: 4, Column: 15, Message: "cannot unmarshal !!map into []string"},
+			},
+		},
+		"Invalid mapping, block": {
+			yaml: `
+on:
+  push:
+    branches:
+      a: b
+`,
+			want: Errors{
+				{Path: "on.push.branches", Line: 5, Column: 7, Message: "cannot unmarshal !!map into []string"},
+			},
+		},
+		"Invalid nested mapping": {
+			yaml: `
+on:
+  push:
+    branches: {a: {b: c}}
+    tags:
+      - a: b
+`,
+			want: Errors{
+				{Path: "on.push.branches", Line: 4, Column: 15, Message: "cannot unmarshal !!map into []string"},
+				{Path: "on.push.tags[0]", Line: 6, Column: 9, Message: "cannot unmarshal !!map into string"},
+			},
+		},
+		"Invalid mapping in a list": {
+			yaml: `
+jobs:
+  build:
+    needs: [a, {b: c}]
+`,
+			want: Errors{
+				{Path: "jobs.build.needs[1]", Line: 4, Column: 16, Message: "cannot unmarshal !!map into string"},
+			},
+		},
+		"Invalid service ports": {
+			yaml: `
+jobs:
+  build:
+    services:
+      db:
+        ports: {p: 1}
+`,
+			want: Errors{
+				{Path: "jobs.build.services.db.ports", Line: 6, Column: 16, Message: "cannot unmarshal !!map into gha.Ports"},
+			},
+		},
+		"Invalid scalar": {
+			yaml: `
+jobs:
+  build:
+    timeout-minutes: 5
+    strategy:
+      max-parallel: {a: 1}
+      matrix: {os: [linux]}
+    env: {FOO: [bar], BAR: baz}
+`,
+			want: Errors{
+				{Path: "jobs.build.strategy.max-parallel", Line: 6, Column: 21, Message: "cannot unmarshal !!map into int"},
+				{Path: "jobs.build.env.FOO", Line: 8, Column: 16, Message: "cannot unmarshal !!seq into string"},
+			},
+		},
+		"Invalid long scalar": {
+			yaml: `
+jobs:
+  build:
+    env: {FOO: bar, BAR: [baz]}
+    timeout-minutes: thirty-five
+`,
+			want: Errors{
+				{Path: "jobs.build.env.BAR", Line: 4, Column: 26, Message: "cannot unmarshal !!seq into string"},
+				{Path: "jobs.build.timeout-minutes", Line: 5, Column: 22, Message: "cannot unmarshal !!str `thirty-...` into int"},
+			},
+		},
+		"Multiple problems": {
+			yaml: `
+on: [push, [fork]]
+concurrency:
+  cancel-in-progress: [true]
+jobs:
+  build:
+    needs: {foo: bar}
+    strategy:
+      matrix:
+        include: [42, {foo: bar}]
+  test:
+    with:
+      foo: [bar]
+`,
+			want: Errors{
+				{Path: "on[1]", Line: 2, Column: 12, Message: "invalid on entry 2"},
+				{Path: "concurrency.cancel-in-progress", Line: 4, Column: 23, Message: "invalid concurrency.cancel-in-progress !!seq"},
+				{Path: "jobs.build.needs", Line: 7, Column: 12, Message: "invalid job.needs 4"},
+				{Path: "jobs.build.strategy.matrix.include[0]", Line: 10, Column: 19, Message: "invalid matrix.include entry 42"},
+				{Path: "jobs.test.with.foo", Line: 13, Column: 12, Message: "cannot unmarshal !!seq into a gha.Value struct"},
+			},
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseWorkflow([]byte(tt.yaml))
+			if err == nil {
+				t.Fatal("Want an error, got none")
+			}
+
+			var got Errors
+			if !errors.As(err, &got) {
+				t.Fatalf("Want an Errors, got %#v", err)
+			}
+
+			checkErrors(t, got, tt.want)
+		})
+	}
+}
+
+func TestParseManifestErrors(t *testing.T) {
+	yaml := `
+name: Example
+runs:
+  using: composite
+  steps:
+    - uses: actions/checkout@
+      with:
+        foo: {bar: baz}
+`
+
+	_, err := ParseManifest([]byte(yaml))
+	if err == nil {
+		t.Fatal("Want an error, got none")
+	}
+
+	var got Errors
+	if !errors.As(err, &got) {
+		t.Fatalf("Want an Errors, got %#v", err)
+	}
+
+	want := Errors{
+		{Path: "runs.steps[0].uses", Line: 6, Column: 13, Message: "invalid `uses` value (\"actions/checkout@\")"},
+		{Path: "runs.steps[0].with.foo", Line: 8, Column: 14, Message: "cannot unmarshal !!map into a gha.Value struct"},
+	}
+
+	checkErrors(t, got, want)
+}
+
+func TestErrorAs(t *testing.T) {
+	_, err := ParseWorkflow([]byte(`on: [[push]]`))
+	if err == nil {
+		t.Fatal("Want an error, got none")
+	}
+
+	var got *Error
+	if !errors.As(err, &got) {
+		t.Fatalf("Want an Error, got %#v", err)
+	}
+
+	if got, want := got.Path, "on[0]"; got != want {
+		t.Errorf("Unexpected path (got %q, want %q)", got, want)
+	}
+}
+
+func TestErrorString(t *testing.T) {
+	type TestCase struct {
+		err  Error
+		want string
+	}
+
+	testCases := map[string]TestCase{
+		"Full": {
+			err:  Error{Path: "jobs.build.steps[2].uses", Line: 8, Column: 15, Message: "invalid"},
+			want: "jobs.build.steps[2].uses (line 8, column 15): invalid",
+		},
+		"No column": {
+			err:  Error{Line: 3, Message: "invalid"},
+			want: "(line 3): invalid",
+		},
+		"No position": {
+			err:  Error{Path: "on", Message: "invalid"},
+			want: "on: invalid",
+		},
+		"Only a message": {
+			err:  Error{Message: "invalid"},
+			want: "invalid",
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got, want := tt.err.Error(), tt.want; got != want {
+				t.Errorf("unexpected result (got %q, want %q)", got, want)
+			}
+		})
+	}
+}
+
+func checkErrors(t *testing.T, got, want Errors) {
+	t.Helper()
+
+	if got, want := len(got), len(want); got != want {
+		t.Fatalf("Unexpected number of errors (got %d, want %d)", got, want)
+	}
+
+	for i, want := range want {
+		if got := got[i]; *got != *want {
+			t.Errorf("Unexpected error %d (got %+v, want %+v)", i, *got, *want)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+// Package expr parses GitHub Actions expressions, e.g. the contents of
+// `${{ github.event_name == 'push' }}`, into an abstract syntax tree and
+// evaluates them against a [Context].
+package expr
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Node is a node in the abstract syntax tree of an expression.
+type Node interface {
+	// Pos returns the byte offset of the start of the node in the expression.
+	Pos() int
+
+	// String returns the source representation of the node. Operations are
+	// parenthesized to make their precedence explicit.
+	String() string
+}
+
+// Null is a `null` literal.
+type Null struct {
+	Offset int
+}
+
+// Bool is a boolean literal, i.e. `true` or `false`.
+type Bool struct {
+	Offset int
+	Value  bool
+}
+
+// Number is a number literal, e.g. `42`, `-3.14`, `0xff` or `1e3`.
+type Number struct {
+	Offset int
+	Value  float64
+
+	// Raw is the literal as it appears in the expression.
+	Raw string
+}
+
+// String is a string literal, e.g. `'foo'`.
+type String struct {
+	Offset int
+
+	// Value is the unquoted value of the literal.
+	Value string
+}
+
+// Ident is a named value, e.g. the `github` context.
+type Ident struct {
+	Offset int
+	Name   string
+}
+
+// Property is a property dereference, e.g. `github.event`.
+type Property struct {
+	X    Node
+	Name string
+}
+
+// Index is an index access, e.g. `github['event']` or `matrix.os[0]`.
+type Index struct {
+	X     Node
+	Index Node
+}
+
+// Filter is an object filter, e.g. the `.*` in `github.event.commits.*.id` or
+// the `[*]` in `needs[*].result`.
+type Filter struct {
+	X Node
+}
+
+// Call is a function call, e.g. `contains(github.ref, 'main')`.
+type Call struct {
+	Offset int
+	Name   string
+	Args   []Node
+}
+
+// Not is a logical not, e.g. `!cancelled()`.
+type Not struct {
+	Offset int
+	X      Node
+}
+
+// Binary is a binary operation, e.g. `github.ref == 'refs/heads/main'`.
+type Binary struct {
+	X  Node
+	Op Operator
+	Y  Node
+
+	// OpOffset is the byte offset of the operator in the expression.
+	OpOffset int
+}
+
+// Operator is a binary operator.
+type Operator string
+
+const (
+	OpAnd       Operator = "&&"
+	OpOr        Operator = "||"
+	OpEq        Operator = "=="
+	OpNotEq     Operator = "!="
+	OpLess      Operator = "<"
+	OpLessEq    Operator = "<="
+	OpGreater   Operator = ">"
+	OpGreaterEq Operator = ">="
+)
+
+func (n *Null) Pos() int     { return n.Offset }
+func (n *Bool) Pos() int     { return n.Offset }
+func (n *Number) Pos() int   { return n.Offset }
+func (n *String) Pos() int   { return n.Offset }
+func (n *Ident) Pos() int    { return n.Offset }
+func (n *Property) Pos() int { return n.X.Pos() }
+func (n *Index) Pos() int    { return n.X.Pos() }
+func (n *Filter) Pos() int   { return n.X.Pos() }
+func (n *Call) Pos() int     { return n.Offset }
+func (n *Not) Pos() int      { return n.Offset }
+func (n *Binary) Pos() int   { return n.X.Pos() }
+
+func (n *Null) String() string {
+	return "null"
+}
+
+func (n *Bool) String() string {
+	return strconv.FormatBool(n.Value)
+}
+
+func (n *Number) String() string {
+	return n.Raw
+}
+
+func (n *String) String() string {
+	return "'" + strings.ReplaceAll(n.Value, "'", "''") + "'"
+}
+
+func (n *Ident) String() string {
+	return n.Name
+}
+
+func (n *Property) String() string {
+	return operand(n.X) + "." + n.Name
+}
+
+func (n *Index) String() string {
+	return n.X.String() + "[" + n.Index.String() + "]"
+}
+
+func (n *Filter) String() string {
+	return operand(n.X) + ".*"
+}
+
+func (n *Call) String() string {
+	args := make([]string, len(n.Args))
+	for i, arg := range n.Args {
+		args[i] = arg.String()
+	}
+
+	return n.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+func (n *Not) String() string {
+	return "!" + n.X.String()
+}
+
+func (n *Binary) String() string {
+	return "(" + n.X.String() + " " + string(n.Op) + " " + n.Y.String() + ")"
+}
+
+// operand returns the source representation of the operand of a property
+// dereference. Numbers are parenthesized because the dot would otherwise be
+// read as a decimal point.
+func operand(n Node) string {
+	if _, ok := n.(*Number); ok {
+		return "(" + n.String() + ")"
+	}
+
+	return n.String()
+}
+
+// Walk traverses the tree rooted at n in depth-first order. It calls f for
+// each node, and skips the children of a node if f returns false.
+func Walk(n Node, f func(Node) bool) {
+	if n == nil || !f(n) {
+		return
+	}
+
+	switch n := n.(type) {
+	case *Property:
+		Walk(n.X, f)
+	case *Index:
+		Walk(n.X, f)
+		Walk(n.Index, f)
+	case *Filter:
+		Walk(n.X, f)
+	case *Call:
+		for _, arg := range n.Args {
+			Walk(arg, f)
+		}
+	case *Not:
+		Walk(n.X, f)
+	case *Binary:
+		Walk(n.X, f)
+		Walk(n.Y, f)
+	}
+}
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package expr
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNodeString(t *testing.T) {
+	type TestCase struct {
+		node Node
+		want string
+	}
+
+	testCases := map[string]TestCase{
+		"String with quote": {
+			node: &String{Value: "it's"},
+			want: `'it''s'`,
+		},
+		"Property of number": {
+			node: &Property{X: &Number{Value: 1, Raw: "1"}, Name: "foo"},
+			want: `(1).foo`,
+		},
+		"Filter of number": {
+			node: &Filter{X: &Number{Value: 1, Raw: "1"}},
+			want: `(1).*`,
+		},
+		"Index": {
+			node: &Index{X: &Ident{Name: "matrix"}, Index: &String{Value: "os"}},
+			want: `matrix['os']`,
+		},
+		"Not of binary": {
+			node: &Not{X: &Binary{X: &Ident{Name: "a"}, Op: OpEq, Y: &Null{}}},
+			want: `!(a == null)`,
+		},
+		"Call": {
+			node: &Call{Name: "always"},
+			want: `always()`,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got, want := tt.node.String(), tt.want; got != want {
+				t.Errorf("unexpected result (got %q, want %q)", got, want)
+			}
+		})
+	}
+}
+
+func TestWalk(t *testing.T) {
+	n, err := Parse(`contains(needs.*.result, 'failure') || !matrix[inputs.key]`)
+	if err != nil {
+		t.Fatalf("Want no error, got %v", err)
+	}
+
+	t.Run("All nodes", func(t *testing.T) {
+		var got []string
+		Walk(n, func(n Node) bool {
+			got = append(got, n.String())
+			return true
+		})
+
+		want := []string{
+			`(contains(needs.*.result, 'failure') || !matrix[inputs.key])`,
+			`contains(needs.*.result, 'failure')`,
+			`needs.*.result`,
+			`needs.*`,
+			`needs`,
+			`'failure'`,
+			`!matrix[inputs.key]`,
+			`matrix[inputs.key]`,
+			`matrix`,
+			`inputs.key`,
+			`inputs`,
+		}
+
+		if !slices.Equal(got, want) {
+			t.Errorf("Unexpected nodes\ngot:  %q\nwant: %q", got, want)
+		}
+	})
+
+	t.Run("Skip children", func(t *testing.T) {
+		var got []string
+		Walk(n, func(n Node) bool {
+			got = append(got, n.String())
+			_, ok := n.(*Call)
+			return !ok
+		})
+
+		if got, want := len(got), 7; got != want {
+			t.Errorf("Unexpected number of nodes (got %d, want %d)", got, want)
+		}
+	})
+}
@@ -0,0 +1,491 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package expr
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Context is what an expression is evaluated against.
+type Context struct {
+	// Values are the contexts that are available to the expression by name,
+	// e.g. "github", "env", "vars", "inputs", "matrix", "needs" or "steps".
+	//
+	// Values are JSON-like, i.e. nil, bool, float64 (or another Go number type),
+	// string, []any or map[string]any.
+	Values map[string]any
+
+	// FS is the workspace that `hashFiles` operates on. If it is nil, calls to
+	// `hashFiles` result in an error.
+	FS fs.FS
+
+	// Status is the status of the job when the expression is evaluated. It
+	// determines the result of the status check functions, e.g. `success()`.
+	Status Status
+}
+
+// Status is the status of a job.
+type Status int
+
+const (
+	StatusSuccess Status = iota
+	StatusFailure
+	StatusCancelled
+)
+
+// filtered is the result of an object filter. Dereferencing it applies to each
+// of its elements.
+type filtered []any
+
+// Eval evaluates the expression n against the context ctx. The result is a
+// JSON-like value, see [Context.Values]. A nil ctx is the same as an empty
+// [Context].
+func Eval(n Node, ctx *Context) (any, error) {
+	if ctx == nil {
+		ctx = &Context{}
+	}
+
+	v, err := eval(n, ctx)
+	if f, ok := v.(filtered); ok {
+		v = []any(f)
+	}
+
+	return v, err
+}
+
+// EvalString evaluates s, which may contain embedded expressions. If s is just
+// one expression its value is returned as is, otherwise the value of every
+// expression is converted to a string and substituted into s.
+func EvalString(s string, ctx *Context) (any, error) {
+	exprs, err := Extract(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(exprs) == 1 && exprs[0].Offset == 0 && exprs[0].End() == len(s) {
+		return Eval(exprs[0].Node, ctx)
+	}
+
+	var (
+		sb     strings.Builder
+		offset int
+	)
+
+	for _, e := range exprs {
+		v, err := Eval(e.Node, ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		sb.WriteString(s[offset:e.Offset])
+		sb.WriteString(toString(v))
+		offset = e.End()
+	}
+
+	sb.WriteString(s[offset:])
+	return sb.String(), nil
+}
+
+// EvalCondition evaluates an `if:` condition, with or without `${{ }}`. As on
+// GitHub, the condition is implicitly combined with `success()` unless it uses
+// a status check function. An empty condition is the same as `success()`.
+func EvalCondition(cond string, ctx *Context) (bool, error) {
+	src := strings.TrimSpace(cond)
+	if strings.HasPrefix(src, exprStart) && strings.HasSuffix(src, exprEnd) &&
+		findEnd(src, len(exprStart)) == len(src)-len(exprEnd) {
+		src = src[len(exprStart) : len(src)-len(exprEnd)]
+	}
+
+	if strings.TrimSpace(src) == "" {
+		src = "success()"
+	}
+
+	n, err := Parse(src)
+	if err != nil {
+		return false, err
+	}
+
+	if !hasStatusCheck(n) {
+		n = &Binary{X: &Call{Name: "success"}, Op: OpAnd, Y: n}
+	}
+
+	v, err := Eval(n, ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return Truthy(v), nil
+}
+
+// Truthy reports whether v is considered true. The values false, 0, -0, NaN,
+// "" and null are falsy, everything else is truthy.
+func Truthy(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case []any, filtered, map[string]any:
+		return true
+	}
+
+	if f, ok := number(v); ok {
+		return f != 0 && !math.IsNaN(f)
+	}
+
+	return true
+}
+
+func eval(n Node, ctx *Context) (any, error) {
+	switch n := n.(type) {
+	case *Null:
+		return nil, nil
+	case *Bool:
+		return n.Value, nil
+	case *Number:
+		return n.Value, nil
+	case *String:
+		return n.Value, nil
+	case *Ident:
+		if v, ok := ctx.Values[n.Name]; ok {
+			return v, nil
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(ctx.Values)) {
+			if strings.EqualFold(name, n.Name) {
+				return ctx.Values[name], nil
+			}
+		}
+
+		return nil, &Error{Pos: n.Pos(), Message: fmt.Sprintf("unknown context %q", n.Name)}
+	case *Property:
+		x, err := eval(n.X, ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return index(x, n.Name), nil
+	case *Index:
+		x, err := eval(n.X, ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		i, err := eval(n.Index, ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return index(x, i), nil
+	case *Filter:
+		x, err := eval(n.X, ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return filter(x), nil
+	case *Call:
+		return call(n, ctx)
+	case *Not:
+		x, err := eval(n.X, ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return !Truthy(x), nil
+	case *Binary:
+		x, err := eval(n.X, ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// The logical operators short-circuit and evaluate to one of their
+		// operands rather than to a boolean.
+		switch n.Op {
+		case OpAnd:
+			if !Truthy(x) {
+				return x, nil
+			}
+
+			return eval(n.Y, ctx)
+		case OpOr:
+			if Truthy(x) {
+				return x, nil
+			}
+
+			return eval(n.Y, ctx)
+		}
+
+		y, err := eval(n.Y, ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return compare(n.Op, x, y), nil
+	default:
+		return nil, &Error{Pos: n.Pos(), Message: fmt.Sprintf("unknown node %T", n)}
+	}
+}
+
+// index returns the property or element i of x, or null if there is none.
+// Property names are case insensitive.
+func index(x, i any) any {
+	switch x := x.(type) {
+	case map[string]any:
+		name, ok := i.(string)
+		if !ok {
+			return nil
+		}
+
+		if v, ok := x[name]; ok {
+			return v
+		}
+
+		for _, k := range slices.Sorted(maps.Keys(x)) {
+			if strings.EqualFold(k, name) {
+				return x[k]
+			}
+		}
+	case []any:
+		f, ok := number(i)
+		if !ok || f != math.Trunc(f) || f < 0 || f >= float64(len(x)) {
+			return nil
+		}
+
+		return x[int(f)]
+	case filtered:
+		var result filtered
+		for _, v := range x {
+			if v := index(v, i); v != nil {
+				result = append(result, v)
+			}
+		}
+
+		return result
+	}
+
+	return nil
+}
+
+// filter returns the elements of an array, or the values of an object.
+func filter(x any) filtered {
+	switch x := x.(type) {
+	case []any:
+		return filtered(x)
+	case map[string]any:
+		result := make(filtered, 0, len(x))
+		for _, k := range slices.Sorted(maps.Keys(x)) {
+			result = append(result, x[k])
+		}
+
+		return result
+	case filtered:
+		var result filtered
+		for _, v := range x {
+			result = append(result, filter(v)...)
+		}
+
+		return result
+	}
+
+	return filtered{}
+}
+
+// compare applies a comparison operator following GitHub's loose equality.
+// Values of different types are converted to numbers, strings are compared
+// case insensitively, and arrays and objects are only equal to themselves.
+func compare(op Operator, x, y any) bool {
+	if op == OpNotEq {
+		return !compare(OpEq, x, y)
+	}
+
+	xs, xok := x.(string)
+	ys, yok := y.(string)
+	if xok && yok {
+		xs, ys = strings.ToUpper(xs), strings.ToUpper(ys)
+		switch op {
+		case OpEq:
+			return xs == ys
+		case OpLess:
+			return xs < ys
+		case OpLessEq:
+			return xs <= ys
+		case OpGreater:
+			return xs > ys
+		case OpGreaterEq:
+			return xs >= ys
+		}
+	}
+
+	if isComposite(x) || isComposite(y) {
+		return op == OpEq && sameComposite(x, y)
+	}
+
+	if x == nil && y == nil {
+		return op == OpEq || op == OpLessEq || op == OpGreaterEq
+	}
+
+	xf, yf := toNumber(x), toNumber(y)
+	switch op {
+	case OpEq:
+		return xf == yf
+	case OpLess:
+		return xf < yf
+	case OpLessEq:
+		return xf <= yf
+	case OpGreater:
+		return xf > yf
+	case OpGreaterEq:
+		return xf >= yf
+	}
+
+	return false
+}
+
+func isComposite(v any) bool {
+	switch v.(type) {
+	case []any, filtered, map[string]any:
+		return true
+	}
+
+	return false
+}
+
+// sameComposite reports whether x and y are the same array or object.
+func sameComposite(x, y any) bool {
+	if !isComposite(x) || !isComposite(y) {
+		return false
+	}
+
+	if f, ok := x.(filtered); ok {
+		x = []any(f)
+	}
+	if f, ok := y.(filtered); ok {
+		y = []any(f)
+	}
+
+	xv, yv := reflect.ValueOf(x), reflect.ValueOf(y)
+	return xv.Type() == yv.Type() && xv.Len() == yv.Len() && xv.Pointer() == yv.Pointer()
+}
+
+// number returns v as a float64 if it is a Go number.
+func number(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+
+	return 0, false
+}
+
+// toNumber converts v to a number. Values that cannot be converted, such as
+// arrays, objects and non-numeric strings, result in NaN.
+func toNumber(v any) float64 {
+	switch v := v.(type) {
+	case nil:
+		return 0
+	case bool:
+		if v {
+			return 1
+		}
+
+		return 0
+	case string:
+		s := strings.TrimSpace(v)
+		if s == "" {
+			return 0
+		}
+
+		f, err := parseNumber(s)
+		if err != nil {
+			return math.NaN()
+		}
+
+		return f
+	}
+
+	if f, ok := number(v); ok {
+		return f
+	}
+
+	return math.NaN()
+}
+
+// toString converts v to a string as GitHub does when substituting values.
+func toString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	case []any, filtered:
+		return "Array"
+	case map[string]any:
+		return "Object"
+	}
+
+	if f, ok := number(v); ok {
+		switch {
+		case math.IsNaN(f):
+			return "NaN"
+		case math.IsInf(f, 1):
+			return "Infinity"
+		case math.IsInf(f, -1):
+			return "-Infinity"
+		}
+
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	return fmt.Sprint(v)
+}
+
+// hasStatusCheck reports whether n calls a status check function.
+func hasStatusCheck(n Node) bool {
+	found := false
+	Walk(n, func(n Node) bool {
+		if call, ok := n.(*Call); ok {
+			switch strings.ToLower(call.Name) {
+			case "success", "failure", "cancelled", "always":
+				found = true
+			}
+		}
+
+		return !found
+	})
+
+	return found
+}
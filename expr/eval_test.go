@@ -0,0 +1,383 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package expr
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func testContext() *Context {
+	return &Context{
+		Values: map[string]any{
+			"github": map[string]any{
+				"event_name": "push",
+				"ref":        "refs/heads/main",
+				"run_number": 42,
+				"event": map[string]any{
+					"commits": []any{
+						map[string]any{"id": "a", "message": "Fix"},
+						map[string]any{"id": "b", "message": "Add"},
+					},
+				},
+			},
+			"env": map[string]any{
+				"DEBUG": "",
+			},
+			"inputs": map[string]any{
+				"dry-run": true,
+				"count":   "3",
+			},
+			"matrix": map[string]any{
+				"os": "ubuntu-latest",
+			},
+			"needs": map[string]any{
+				"build": map[string]any{"result": "success"},
+				"test":  map[string]any{"result": "failure"},
+			},
+			"steps": map[string]any{},
+		},
+	}
+}
+
+func TestEval(t *testing.T) {
+	type TestCase struct {
+		src  string
+		want any
+	}
+
+	okCases := map[string]TestCase{
+		"Literal": {
+			src:  `'foo'`,
+			want: "foo",
+		},
+		"Property": {
+			src:  `github.event_name`,
+			want: "push",
+		},
+		"Case insensitive property": {
+			src:  `GitHub.Event_Name`,
+			want: "push",
+		},
+		"Index": {
+			src:  `github['ref']`,
+			want: "refs/heads/main",
+		},
+		"Array index": {
+			src:  `github.event.commits[1].id`,
+			want: "b",
+		},
+		"Array index out of range": {
+			src:  `github.event.commits[2]`,
+			want: nil,
+		},
+		"Missing property": {
+			src:  `github.foo.bar`,
+			want: nil,
+		},
+		"Property filter": {
+			src:  `github.event.commits.*.id`,
+			want: []any{"a", "b"},
+		},
+		"Object filter": {
+			src:  `needs.*.result`,
+			want: []any{"success", "failure"},
+		},
+		"And evaluates to an operand": {
+			src:  `inputs.dry-run && 'yes'`,
+			want: "yes",
+		},
+		"Or evaluates to an operand": {
+			src:  `env.DEBUG || 'default'`,
+			want: "default",
+		},
+		"Or short-circuits": {
+			src:  `true || unknown.context`,
+			want: true,
+		},
+		"Not": {
+			src:  `!env.DEBUG`,
+			want: true,
+		},
+		"Case insensitive string equality": {
+			src:  `matrix.os == 'Ubuntu-Latest'`,
+			want: true,
+		},
+		"Loose equality, number and string": {
+			src:  `inputs.count == 3`,
+			want: true,
+		},
+		"Loose equality, boolean and number": {
+			src:  `true == 1`,
+			want: true,
+		},
+		"Loose equality, null and number": {
+			src:  `null == 0`,
+			want: true,
+		},
+		"Loose equality, empty string and false": {
+			src:  `'' == false`,
+			want: true,
+		},
+		"Loose equality, non-numeric string": {
+			src:  `'foo' == 0`,
+			want: false,
+		},
+		"Non-numeric string is not equal to a number": {
+			src:  `'foo' != 0`,
+			want: true,
+		},
+		"Objects are not equal to copies": {
+			src:  `fromJSON('{}') == fromJSON('{}')`,
+			want: false,
+		},
+		"Objects are equal to themselves": {
+			src:  `github.event == github.event`,
+			want: true,
+		},
+		"Number comparison": {
+			src:  `github.run_number > 9`,
+			want: true,
+		},
+		"String comparison": {
+			src:  `'b' >= 'A'`,
+			want: true,
+		},
+		"Hexadecimal number": {
+			src:  `0x10 == 16`,
+			want: true,
+		},
+	}
+
+	for name, tt := range okCases {
+		t.Run(name, func(t *testing.T) {
+			n, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Want no error, got %v", err)
+			}
+
+			got, err := Eval(n, testContext())
+			if err != nil {
+				t.Fatalf("Want no error, got %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unexpected result (got %#v, want %#v)", got, tt.want)
+			}
+		})
+	}
+
+	errCases := map[string]TestCase{
+		"Unknown context": {
+			src: `secrets.TOKEN`,
+		},
+		"Unknown function": {
+			src: `foo()`,
+		},
+		"Error in operand": {
+			src: `true && secrets.TOKEN`,
+		},
+	}
+
+	for name, tt := range errCases {
+		t.Run(name, func(t *testing.T) {
+			n, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Want no error, got %v", err)
+			}
+
+			_, err = Eval(n, testContext())
+			if err == nil {
+				t.Fatal("Want an error, got none")
+			}
+
+			var exprErr *Error
+			if !errors.As(err, &exprErr) {
+				t.Errorf("Want an Error, got %#v", err)
+			}
+		})
+	}
+}
+
+func TestEvalString(t *testing.T) {
+	type TestCase struct {
+		s    string
+		want any
+	}
+
+	testCases := map[string]TestCase{
+		"No expressions": {
+			s:    `echo 'Hello world!'`,
+			want: `echo 'Hello world!'`,
+		},
+		"Only an expression": {
+			s:    `${{ inputs.dry-run }}`,
+			want: true,
+		},
+		"Embedded expressions": {
+			s:    `Run ${{ github.run_number }} on ${{ matrix.os }}`,
+			want: `Run 42 on ubuntu-latest`,
+		},
+		"Embedded null, array and object": {
+			s:    `[${{ github.foo }}] ${{ github.event.commits }} ${{ github.event }}`,
+			want: `[] Array Object`,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := EvalString(tt.s, testContext())
+			if err != nil {
+				t.Fatalf("Want no error, got %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unexpected result (got %#v, want %#v)", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalCondition(t *testing.T) {
+	type TestCase struct {
+		cond   string
+		status Status
+		want   bool
+	}
+
+	testCases := map[string]TestCase{
+		"Empty": {
+			cond: ``,
+			want: true,
+		},
+		"Empty after a failure": {
+			cond:   ``,
+			status: StatusFailure,
+			want:   false,
+		},
+		"Without braces": {
+			cond: `github.event_name == 'push'`,
+			want: true,
+		},
+		"With braces": {
+			cond: `${{ github.event_name == 'pull_request' }}`,
+			want: false,
+		},
+		"Implicit success after a failure": {
+			cond:   `github.event_name == 'push'`,
+			status: StatusFailure,
+			want:   false,
+		},
+		"Explicit failure": {
+			cond:   `failure() && github.event_name == 'push'`,
+			status: StatusFailure,
+			want:   true,
+		},
+		"Always after cancellation": {
+			cond:   `${{ always() }}`,
+			status: StatusCancelled,
+			want:   true,
+		},
+		"Cancelled": {
+			cond:   `cancelled()`,
+			status: StatusCancelled,
+			want:   true,
+		},
+		"Success after cancellation": {
+			cond:   `success()`,
+			status: StatusCancelled,
+			want:   false,
+		},
+		"Case insensitive status function": {
+			cond:   `Always()`,
+			status: StatusFailure,
+			want:   true,
+		},
+		"Needs": {
+			cond:   `contains(needs.*.result, 'failure')`,
+			status: StatusFailure,
+			want:   false,
+		},
+		"Truthy string": {
+			cond: `matrix.os`,
+			want: true,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := testContext()
+			ctx.Status = tt.status
+
+			got, err := EvalCondition(tt.cond, ctx)
+			if err != nil {
+				t.Fatalf("Want no error, got %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("unexpected result (got %t, want %t)", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalNilContext(t *testing.T) {
+	n, err := Parse(`github.ref`)
+	if err != nil {
+		t.Fatalf("Want no error, got %v", err)
+	}
+
+	var exprErr *Error
+	if _, err := Eval(n, nil); !errors.As(err, &exprErr) {
+		t.Errorf("Want an Error, got %#v", err)
+	}
+
+	got, err := EvalString(`Run ${{ 2 }}`, nil)
+	if err != nil {
+		t.Fatalf("Want no error, got %v", err)
+	}
+
+	if want := `Run 2`; got != want {
+		t.Errorf("Unexpected result (got %#v, want %#v)", got, want)
+	}
+
+	ok, err := EvalCondition(``, nil)
+	if err != nil {
+		t.Fatalf("Want no error, got %v", err)
+	}
+
+	if !ok {
+		t.Error("unexpected result (got false, want true)")
+	}
+}
+
+func TestTruthy(t *testing.T) {
+	type TestCase struct {
+		value any
+		want  bool
+	}
+
+	testCases := map[string]TestCase{
+		"null":         {value: nil, want: false},
+		"false":        {value: false, want: false},
+		"true":         {value: true, want: true},
+		"zero":         {value: 0.0, want: false},
+		"negative one": {value: -1.0, want: true},
+		"integer":      {value: 7, want: true},
+		"NaN":          {value: math.NaN(), want: false},
+		"empty string": {value: "", want: false},
+		"string":       {value: "false", want: true},
+		"empty array":  {value: []any{}, want: true},
+		"empty object": {value: map[string]any{}, want: true},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got, want := Truthy(tt.value), tt.want; got != want {
+				t.Errorf("unexpected result (got %t, want %t)", got, want)
+			}
+		})
+	}
+}
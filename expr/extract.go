@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package expr
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	exprStart = "${{"
+	exprEnd   = "}}"
+)
+
+// Expression is an expression embedded in a string, e.g. the `${{ github.ref }}`
+// in `ref: ${{ github.ref }}`.
+type Expression struct {
+	// Offset is the byte offset of the `${{` in the string.
+	Offset int
+
+	// Source is the expression without the surrounding `${{ }}`. It starts at
+	// byte offset Offset+3 in the string.
+	Source string
+
+	// Node is the parsed expression. Its positions are relative to Source.
+	Node Node
+}
+
+// End returns the byte offset just after the `}}` of the expression.
+func (e *Expression) End() int {
+	return e.Offset + len(exprStart) + len(e.Source) + len(exprEnd)
+}
+
+// Extract finds all expressions embedded in s and parses them. Expressions that
+// cannot be parsed are omitted from the result and reported in the error, with
+// their position relative to s.
+func Extract(s string) ([]Expression, error) {
+	var (
+		result []Expression
+		errs   []error
+	)
+
+	for offset := 0; ; {
+		i := strings.Index(s[offset:], exprStart)
+		if i == -1 {
+			break
+		}
+
+		start := offset + i
+		begin := start + len(exprStart)
+
+		end := findEnd(s, begin)
+		if end == -1 {
+			errs = append(errs, &Error{Pos: start, Message: "unterminated expression"})
+			break
+		}
+
+		src := s[begin:end]
+		offset = end + len(exprEnd)
+
+		n, err := Parse(src)
+		if err != nil {
+			var exprErr *Error
+			if errors.As(err, &exprErr) {
+				err = &Error{Pos: begin + exprErr.Pos, Message: exprErr.Message}
+			}
+
+			errs = append(errs, err)
+			continue
+		}
+
+		result = append(result, Expression{Offset: start, Source: src, Node: n})
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// findEnd returns the byte offset of the `}}` that closes the expression that
+// starts at offset in s, ignoring any `}}` in string literals. It returns -1 if
+// the expression is not closed.
+func findEnd(s string, offset int) int {
+	inString := false
+	for i := offset; i < len(s); i++ {
+		switch {
+		case s[i] == '\'':
+			inString = !inString
+		case !inString && strings.HasPrefix(s[i:], exprEnd):
+			return i
+		}
+	}
+
+	return -1
+}
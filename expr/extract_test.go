@@ -0,0 +1,143 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package expr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	type Want struct {
+		offset int
+		source string
+		node   string
+	}
+
+	type TestCase struct {
+		s    string
+		want []Want
+	}
+
+	okCases := map[string]TestCase{
+		"No expressions": {
+			s:    `echo 'Hello world!'`,
+			want: nil,
+		},
+		"Only an expression": {
+			s: `${{ github.ref }}`,
+			want: []Want{
+				{offset: 0, source: " github.ref ", node: "github.ref"},
+			},
+		},
+		"Multiple expressions": {
+			s: `echo "${{ github.actor }} on ${{github.ref_name}}"`,
+			want: []Want{
+				{offset: 6, source: " github.actor ", node: "github.actor"},
+				{offset: 29, source: "github.ref_name", node: "github.ref_name"},
+			},
+		},
+		"Closing braces in a string": {
+			s: `${{ format('{{{0}}}', 'x') }}!`,
+			want: []Want{
+				{offset: 0, source: " format('{{{0}}}', 'x') ", node: "format('{{{0}}}', 'x')"},
+			},
+		},
+		"Multi-line": {
+			s: "if [ \"${{\n  inputs.debug\n}}\" = 'true' ]; then\n  set -x\nfi",
+			want: []Want{
+				{offset: 6, source: "\n  inputs.debug\n", node: "inputs.debug"},
+			},
+		},
+	}
+
+	for name, tt := range okCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := Extract(tt.s)
+			if err != nil {
+				t.Fatalf("Want no error, got %v", err)
+			}
+
+			if got, want := len(got), len(tt.want); got != want {
+				t.Fatalf("Unexpected number of expressions (got %d, want %d)", got, want)
+			}
+
+			for i, want := range tt.want {
+				got := got[i]
+				if got, want := got.Offset, want.offset; got != want {
+					t.Errorf("Unexpected offset of expression %d (got %d, want %d)", i, got, want)
+				}
+
+				if got, want := got.Source, want.source; got != want {
+					t.Errorf("Unexpected source of expression %d (got %q, want %q)", i, got, want)
+				}
+
+				if got, want := got.Node.String(), want.node; got != want {
+					t.Errorf("Unexpected node of expression %d (got %q, want %q)", i, got, want)
+				}
+			}
+		})
+	}
+
+	type ErrCase struct {
+		s     string
+		valid int
+		pos   []int
+	}
+
+	errCases := map[string]ErrCase{
+		"Unterminated expression": {
+			s:     `${{ github.ref } and more`,
+			valid: 0,
+			pos:   []int{0},
+		},
+		"Invalid expression": {
+			s:     `${{ github.ref }} ${{ a = b }}`,
+			valid: 1,
+			pos:   []int{24},
+		},
+		"Unterminated string in expression": {
+			s:     `${{ 'foo }} ${{ a && }} ${{ github.ref }}`,
+			valid: 0,
+			pos:   []int{0},
+		},
+		"Invalid and unterminated expressions": {
+			s:     `${{ a b }} ${{ github.ref }} ${{`,
+			valid: 1,
+			pos:   []int{6, 29},
+		},
+	}
+
+	for name, tt := range errCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := Extract(tt.s)
+			if err == nil {
+				t.Fatal("Want an error, got none")
+			}
+
+			if got, want := len(got), tt.valid; got != want {
+				t.Errorf("Unexpected number of expressions (got %d, want %d)", got, want)
+			}
+
+			errs, ok := err.(interface{ Unwrap() []error })
+			if !ok {
+				t.Fatalf("Want multiple errors, got %#v", err)
+			}
+
+			if got, want := len(errs.Unwrap()), len(tt.pos); got != want {
+				t.Fatalf("Unexpected number of errors (got %d, want %d)", got, want)
+			}
+
+			for i, err := range errs.Unwrap() {
+				var got *Error
+				if !errors.As(err, &got) {
+					t.Fatalf("Want an Error, got %#v", err)
+				}
+
+				if got, want := got.Pos, tt.pos[i]; got != want {
+					t.Errorf("Unexpected position of error %d (got %d, want %d)", i, got, want)
+				}
+			}
+		})
+	}
+}
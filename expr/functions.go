@@ -0,0 +1,273 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package expr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// function is a built-in function. A maxArgs of -1 means there is no maximum.
+type function struct {
+	minArgs int
+	maxArgs int
+	call    func(ctx *Context, args []any) (any, error)
+}
+
+// functions are the built-in functions by their lowercase name.
+var functions = map[string]function{
+	"contains":   {2, 2, contains},
+	"startswith": {2, 2, startsWith},
+	"endswith":   {2, 2, endsWith},
+	"format":     {1, -1, format},
+	"join":       {1, 2, join},
+	"tojson":     {1, 1, toJSON},
+	"fromjson":   {1, 1, fromJSON},
+	"hashfiles":  {1, -1, hashFiles},
+	"success":    {0, 0, success},
+	"always":     {0, 0, always},
+	"cancelled":  {0, 0, cancelled},
+	"failure":    {0, 0, failure},
+}
+
+// call evaluates a function call. Function names are case insensitive.
+func call(n *Call, ctx *Context) (any, error) {
+	f, ok := functions[strings.ToLower(n.Name)]
+	if !ok {
+		return nil, &Error{Pos: n.Pos(), Message: fmt.Sprintf("unknown function %q", n.Name)}
+	}
+
+	if len(n.Args) < f.minArgs || (f.maxArgs >= 0 && len(n.Args) > f.maxArgs) {
+		return nil, &Error{
+			Pos:     n.Pos(),
+			Message: fmt.Sprintf("wrong number of arguments for %s (got %d)", n.Name, len(n.Args)),
+		}
+	}
+
+	args := make([]any, len(n.Args))
+	for i, arg := range n.Args {
+		v, err := Eval(arg, ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		args[i] = v
+	}
+
+	v, err := f.call(ctx, args)
+	if err != nil {
+		return nil, &Error{Pos: n.Pos(), Message: fmt.Sprintf("%s: %v", n.Name, err)}
+	}
+
+	return v, nil
+}
+
+func contains(_ *Context, args []any) (any, error) {
+	if list, ok := args[0].([]any); ok {
+		for _, v := range list {
+			if compare(OpEq, v, args[1]) {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+
+	search, item := strings.ToUpper(toString(args[0])), strings.ToUpper(toString(args[1]))
+	return strings.Contains(search, item), nil
+}
+
+func startsWith(_ *Context, args []any) (any, error) {
+	search, item := strings.ToUpper(toString(args[0])), strings.ToUpper(toString(args[1]))
+	return strings.HasPrefix(search, item), nil
+}
+
+func endsWith(_ *Context, args []any) (any, error) {
+	search, item := strings.ToUpper(toString(args[0])), strings.ToUpper(toString(args[1]))
+	return strings.HasSuffix(search, item), nil
+}
+
+// format replaces `{N}` in the format string with the N-th argument. Braces
+// are escaped by doubling them.
+func format(_ *Context, args []any) (any, error) {
+	s := toString(args[0])
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '{' && i+1 < len(s) && s[i+1] == '{':
+			sb.WriteByte('{')
+			i++
+		case c == '}' && i+1 < len(s) && s[i+1] == '}':
+			sb.WriteByte('}')
+			i++
+		case c == '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end == -1 {
+				return nil, fmt.Errorf("unclosed '{' at %d", i)
+			}
+
+			j, err := strconv.Atoi(s[i+1 : i+end])
+			if err != nil || j < 0 || j+1 >= len(args) {
+				return nil, fmt.Errorf("invalid argument reference %q", s[i:i+end+1])
+			}
+
+			sb.WriteString(toString(args[j+1]))
+			i += end
+		case c == '}':
+			return nil, fmt.Errorf("unescaped '}' at %d", i)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return sb.String(), nil
+}
+
+func join(_ *Context, args []any) (any, error) {
+	sep := ","
+	if len(args) > 1 {
+		sep = toString(args[1])
+	}
+
+	list, ok := args[0].([]any)
+	if !ok {
+		return toString(args[0]), nil
+	}
+
+	values := make([]string, len(list))
+	for i, v := range list {
+		values[i] = toString(v)
+	}
+
+	return strings.Join(values, sep), nil
+}
+
+func toJSON(_ *Context, args []any) (any, error) {
+	data, err := json.MarshalIndent(args[0], "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+func fromJSON(_ *Context, args []any) (any, error) {
+	var v any
+	if err := json.Unmarshal([]byte(toString(args[0])), &v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// hashFiles returns the SHA-256 hash of the SHA-256 hashes of the files that
+// match any of the patterns, or an empty string if no file matches. Patterns
+// starting with `!` exclude files matched by earlier patterns.
+func hashFiles(ctx *Context, args []any) (any, error) {
+	if ctx.FS == nil {
+		return nil, errors.New("no file system available")
+	}
+
+	patterns := make([]string, len(args))
+	for i, arg := range args {
+		patterns[i] = toString(arg)
+	}
+
+	h := sha256.New()
+	matched := false
+
+	err := fs.WalkDir(ctx.FS, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !matchAny(patterns, name) {
+			return err
+		}
+
+		data, err := fs.ReadFile(ctx.FS, name)
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(data)
+		h.Write(sum[:])
+		matched = true
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !matched {
+		return "", nil
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// matchAny reports whether name is matched by the patterns, where the last
+// matching pattern decides.
+func matchAny(patterns []string, name string) bool {
+	matched := false
+	for _, pattern := range patterns {
+		negate := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "./")
+
+		if matchGlob(strings.Split(pattern, "/"), strings.Split(name, "/")) {
+			matched = !negate
+		}
+	}
+
+	return matched
+}
+
+// matchGlob reports whether the path segments match the pattern segments. The
+// segment `**` matches any number of segments.
+func matchGlob(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchGlob(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+
+	return matchGlob(pattern[1:], segments[1:])
+}
+
+func success(ctx *Context, _ []any) (any, error) {
+	return ctx.Status == StatusSuccess, nil
+}
+
+func always(_ *Context, _ []any) (any, error) {
+	return true, nil
+}
+
+func cancelled(ctx *Context, _ []any) (any, error) {
+	return ctx.Status == StatusCancelled, nil
+}
+
+func failure(ctx *Context, _ []any) (any, error) {
+	return ctx.Status == StatusFailure, nil
+}
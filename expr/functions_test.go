@@ -0,0 +1,300 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package expr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFunctions(t *testing.T) {
+	type TestCase struct {
+		src  string
+		want any
+	}
+
+	okCases := map[string]TestCase{
+		"contains, string": {
+			src:  `contains('Hello world', 'WORLD')`,
+			want: true,
+		},
+		"contains, string without match": {
+			src:  `contains('Hello world', 'foo')`,
+			want: false,
+		},
+		"contains, array": {
+			src:  `contains(fromJSON('["push", "pull_request"]'), 'Push')`,
+			want: true,
+		},
+		"contains, array without match": {
+			src:  `contains(fromJSON('["push", "pull_request"]'), 'pull')`,
+			want: false,
+		},
+		"contains, filter": {
+			src:  `contains(github.event.commits.*.message, 'fix')`,
+			want: true,
+		},
+		"startsWith": {
+			src:  `startsWith(github.ref, 'REFS/heads/')`,
+			want: true,
+		},
+		"startsWith, number": {
+			src:  `startsWith(42, 4)`,
+			want: true,
+		},
+		"endsWith": {
+			src:  `endsWith(github.ref, '/dev')`,
+			want: false,
+		},
+		"format": {
+			src:  `format('{0} ran {1} times', 'job', 3)`,
+			want: "job ran 3 times",
+		},
+		"format, repeated argument": {
+			src:  `format('{0}-{0}', 'a')`,
+			want: "a-a",
+		},
+		"format, escaped braces": {
+			src:  `format('{{0}} is {0}', 'x')`,
+			want: "{0} is x",
+		},
+		"join, array": {
+			src:  `join(github.event.commits.*.id)`,
+			want: "a,b",
+		},
+		"join, separator": {
+			src:  `join(github.event.commits.*.id, ' | ')`,
+			want: "a | b",
+		},
+		"join, string": {
+			src:  `join('foo', ', ')`,
+			want: "foo",
+		},
+		"toJSON": {
+			src:  `toJSON(matrix)`,
+			want: "{\n  \"os\": \"ubuntu-latest\"\n}",
+		},
+		"toJSON, null": {
+			src:  `toJSON(null)`,
+			want: "null",
+		},
+		"fromJSON, number": {
+			src:  `fromJSON('3.5')`,
+			want: 3.5,
+		},
+		"fromJSON, object": {
+			src:  `fromJSON('{"a": [true, null]}')`,
+			want: map[string]any{"a": []any{true, nil}},
+		},
+		"Case insensitive name": {
+			src:  `CONTAINS('abc', 'b')`,
+			want: true,
+		},
+	}
+
+	for name, tt := range okCases {
+		t.Run(name, func(t *testing.T) {
+			n, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Want no error, got %v", err)
+			}
+
+			got, err := Eval(n, testContext())
+			if err != nil {
+				t.Fatalf("Want no error, got %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unexpected result (got %#v, want %#v)", got, tt.want)
+			}
+		})
+	}
+
+	errCases := map[string]TestCase{
+		"Too few arguments": {
+			src: `contains('foo')`,
+		},
+		"Too many arguments": {
+			src: `success(1)`,
+		},
+		"format, missing argument": {
+			src: `format('{0} {1}', 'a')`,
+		},
+		"format, invalid reference": {
+			src: `format('{a}', 'a')`,
+		},
+		"format, unclosed brace": {
+			src: `format('{0', 'a')`,
+		},
+		"format, unescaped closing brace": {
+			src: `format('0}', 'a')`,
+		},
+		"fromJSON, invalid": {
+			src: `fromJSON('{')`,
+		},
+		"hashFiles, no file system": {
+			src: `hashFiles('**/go.sum')`,
+		},
+	}
+
+	for name, tt := range errCases {
+		t.Run(name, func(t *testing.T) {
+			n, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Want no error, got %v", err)
+			}
+
+			_, err = Eval(n, testContext())
+			if err == nil {
+				t.Fatal("Want an error, got none")
+			}
+
+			var exprErr *Error
+			if !errors.As(err, &exprErr) {
+				t.Errorf("Want an Error, got %#v", err)
+			} else if got, want := exprErr.Pos, 0; got != want {
+				t.Errorf("Unexpected position (got %d, want %d)", got, want)
+			}
+		})
+	}
+}
+
+func TestHashFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"go.mod":            {Data: []byte("module foo")},
+		"go.sum":            {Data: []byte("bar v1.0.0")},
+		"vendor/go.sum":     {Data: []byte("baz v1.0.0")},
+		"vendor/a/b/go.sum": {Data: []byte("qux v1.0.0")},
+	}
+
+	hash := func(files ...string) string {
+		h := sha256.New()
+		for _, file := range files {
+			sum := sha256.Sum256(fsys[file].Data)
+			h.Write(sum[:])
+		}
+
+		return hex.EncodeToString(h.Sum(nil))
+	}
+
+	type TestCase struct {
+		src  string
+		want string
+	}
+
+	testCases := map[string]TestCase{
+		"Single file": {
+			src:  `hashFiles('go.mod')`,
+			want: hash("go.mod"),
+		},
+		"Leading ./": {
+			src:  `hashFiles('./go.mod')`,
+			want: hash("go.mod"),
+		},
+		"Wildcard": {
+			src:  `hashFiles('go.*')`,
+			want: hash("go.mod", "go.sum"),
+		},
+		"Globstar": {
+			src:  `hashFiles('**/go.sum')`,
+			want: hash("go.sum", "vendor/a/b/go.sum", "vendor/go.sum"),
+		},
+		"Multiple patterns": {
+			src:  `hashFiles('go.mod', 'vendor/*/*/go.sum')`,
+			want: hash("go.mod", "vendor/a/b/go.sum"),
+		},
+		"Negation": {
+			src:  `hashFiles('**/go.sum', '!vendor/**')`,
+			want: hash("go.sum"),
+		},
+		"No match": {
+			src:  `hashFiles('package-lock.json')`,
+			want: "",
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			n, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Want no error, got %v", err)
+			}
+
+			ctx := testContext()
+			ctx.FS = fsys
+
+			got, err := Eval(n, ctx)
+			if err != nil {
+				t.Fatalf("Want no error, got %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Unexpected result (got %q, want %q)", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusFunctions(t *testing.T) {
+	type TestCase struct {
+		status Status
+		want   map[string]bool
+	}
+
+	testCases := map[string]TestCase{
+		"Success": {
+			status: StatusSuccess,
+			want: map[string]bool{
+				"success()":   true,
+				"failure()":   false,
+				"cancelled()": false,
+				"always()":    true,
+			},
+		},
+		"Failure": {
+			status: StatusFailure,
+			want: map[string]bool{
+				"success()":   false,
+				"failure()":   true,
+				"cancelled()": false,
+				"always()":    true,
+			},
+		},
+		"Cancelled": {
+			status: StatusCancelled,
+			want: map[string]bool{
+				"success()":   false,
+				"failure()":   false,
+				"cancelled()": true,
+				"always()":    true,
+			},
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := testContext()
+			ctx.Status = tt.status
+
+			for src, want := range tt.want {
+				n, err := Parse(src)
+				if err != nil {
+					t.Fatalf("Want no error, got %v", err)
+				}
+
+				got, err := Eval(n, ctx)
+				if err != nil {
+					t.Fatalf("Want no error, got %v", err)
+				}
+
+				if got != want {
+					t.Errorf("Unexpected result for %s (got %v, want %t)", src, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package expr
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNull
+	tokenBool
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenDot
+	tokenComma
+	tokenStar
+	tokenLeftParen
+	tokenRightParen
+	tokenLeftBracket
+	tokenRightBracket
+	tokenNot
+	tokenAnd
+	tokenOr
+	tokenEq
+	tokenNotEq
+	tokenLess
+	tokenLessEq
+	tokenGreater
+	tokenGreaterEq
+)
+
+func (k tokenKind) String() string {
+	switch k {
+	case tokenEOF:
+		return "end of expression"
+	case tokenNull:
+		return "null"
+	case tokenBool:
+		return "boolean"
+	case tokenNumber:
+		return "number"
+	case tokenString:
+		return "string"
+	case tokenIdent:
+		return "identifier"
+	case tokenDot:
+		return "'.'"
+	case tokenComma:
+		return "','"
+	case tokenStar:
+		return "'*'"
+	case tokenLeftParen:
+		return "'('"
+	case tokenRightParen:
+		return "')'"
+	case tokenLeftBracket:
+		return "'['"
+	case tokenRightBracket:
+		return "']'"
+	case tokenNot:
+		return "'!'"
+	case tokenAnd:
+		return "'&&'"
+	case tokenOr:
+		return "'||'"
+	case tokenEq:
+		return "'=='"
+	case tokenNotEq:
+		return "'!='"
+	case tokenLess:
+		return "'<'"
+	case tokenLessEq:
+		return "'<='"
+	case tokenGreater:
+		return "'>'"
+	case tokenGreaterEq:
+		return "'>='"
+	default:
+		return fmt.Sprintf("token(%d)", int(k))
+	}
+}
+
+// token is a lexical token of an expression. For string tokens the value is
+// the unquoted string, for other tokens it is the source text.
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// lexer splits an expression into tokens.
+type lexer struct {
+	src string
+	pos int
+}
+
+// lex returns all tokens in src, ending with a tokenEOF.
+func lex(src string) ([]token, error) {
+	l := lexer{src: src}
+
+	var tokens []token
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, tok)
+		if tok.kind == tokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+// next returns the next token in the source.
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) && isSpace(l.src[l.pos]) {
+		l.pos++
+	}
+
+	start := l.pos
+	if start >= len(l.src) {
+		return token{kind: tokenEOF, pos: start}, nil
+	}
+
+	emit := func(kind tokenKind, n int) (token, error) {
+		l.pos += n
+		return token{kind: kind, value: l.src[start:l.pos], pos: start}, nil
+	}
+
+	c := l.src[start]
+	switch c {
+	case '.':
+		if start+1 < len(l.src) && isDigit(l.src[start+1]) {
+			return l.number()
+		}
+
+		return emit(tokenDot, 1)
+	case ',':
+		return emit(tokenComma, 1)
+	case '*':
+		return emit(tokenStar, 1)
+	case '(':
+		return emit(tokenLeftParen, 1)
+	case ')':
+		return emit(tokenRightParen, 1)
+	case '[':
+		return emit(tokenLeftBracket, 1)
+	case ']':
+		return emit(tokenRightBracket, 1)
+	case '!':
+		if l.peek(1) == '=' {
+			return emit(tokenNotEq, 2)
+		}
+
+		return emit(tokenNot, 1)
+	case '=':
+		if l.peek(1) == '=' {
+			return emit(tokenEq, 2)
+		}
+	case '<':
+		if l.peek(1) == '=' {
+			return emit(tokenLessEq, 2)
+		}
+
+		return emit(tokenLess, 1)
+	case '>':
+		if l.peek(1) == '=' {
+			return emit(tokenGreaterEq, 2)
+		}
+
+		return emit(tokenGreater, 1)
+	case '&':
+		if l.peek(1) == '&' {
+			return emit(tokenAnd, 2)
+		}
+	case '|':
+		if l.peek(1) == '|' {
+			return emit(tokenOr, 2)
+		}
+	case '\'':
+		return l.string()
+	case '-':
+		if isDigit(l.peek(1)) || l.peek(1) == '.' {
+			return l.number()
+		}
+	default:
+		switch {
+		case isDigit(c):
+			return l.number()
+		case isIdentStart(c):
+			return l.ident()
+		}
+	}
+
+	return token{}, &Error{Pos: start, Message: fmt.Sprintf("unexpected character %q", c)}
+}
+
+// peek returns the byte n positions after the current position, or 0 at the
+// end of the source.
+func (l *lexer) peek(n int) byte {
+	if l.pos+n >= len(l.src) {
+		return 0
+	}
+
+	return l.src[l.pos+n]
+}
+
+func (l *lexer) ident() (token, error) {
+	start := l.pos
+	for l.pos < len(l.src) && isIdentPart(l.src[l.pos]) {
+		l.pos++
+	}
+
+	value := l.src[start:l.pos]
+	switch value {
+	case "null":
+		return token{kind: tokenNull, value: value, pos: start}, nil
+	case "true", "false":
+		return token{kind: tokenBool, value: value, pos: start}, nil
+	default:
+		return token{kind: tokenIdent, value: value, pos: start}, nil
+	}
+}
+
+func (l *lexer) number() (token, error) {
+	start := l.pos
+	l.pos++ // a sign, digit or dot
+	for l.pos < len(l.src) && isNumberPart(l.src[l.pos], l.src[l.pos-1]) {
+		l.pos++
+	}
+
+	return token{kind: tokenNumber, value: l.src[start:l.pos], pos: start}, nil
+}
+
+func (l *lexer) string() (token, error) {
+	start := l.pos
+	l.pos++
+
+	var sb strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		l.pos++
+
+		if c != '\'' {
+			sb.WriteByte(c)
+			continue
+		}
+
+		// A quote is escaped by doubling it.
+		if l.pos < len(l.src) && l.src[l.pos] == '\'' {
+			sb.WriteByte('\'')
+			l.pos++
+			continue
+		}
+
+		return token{kind: tokenString, value: sb.String(), pos: start}, nil
+	}
+
+	return token{}, &Error{Pos: start, Message: "unterminated string"}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c) || c == '-'
+}
+
+// isNumberPart reports whether c, following prev, can be part of a number
+// literal. This is deliberately loose, e.g. it accepts "0x1F" and "1e-3", the
+// parser validates the literal as a whole.
+func isNumberPart(c, prev byte) bool {
+	switch {
+	case isDigit(c), c == '.':
+		return true
+	case (c == '+' || c == '-') && (prev == 'e' || prev == 'E'):
+		return true
+	case isIdentStart(c):
+		return true
+	default:
+		return false
+	}
+}
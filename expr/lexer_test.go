@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package expr
+
+import (
+	"testing"
+)
+
+func TestLex(t *testing.T) {
+	type TestCase struct {
+		src    string
+		tokens []token
+	}
+
+	okCases := map[string]TestCase{
+		"Empty": {
+			src: ``,
+			tokens: []token{
+				{kind: tokenEOF, pos: 0},
+			},
+		},
+		"Literals": {
+			src: `null true false 42 'foo'`,
+			tokens: []token{
+				{kind: tokenNull, value: "null", pos: 0},
+				{kind: tokenBool, value: "true", pos: 5},
+				{kind: tokenBool, value: "false", pos: 10},
+				{kind: tokenNumber, value: "42", pos: 16},
+				{kind: tokenString, value: "foo", pos: 19},
+				{kind: tokenEOF, pos: 24},
+			},
+		},
+		"Numbers": {
+			src: `-1 3.14 .5 0xff 1e-3 2E+10`,
+			tokens: []token{
+				{kind: tokenNumber, value: "-1", pos: 0},
+				{kind: tokenNumber, value: "3.14", pos: 3},
+				{kind: tokenNumber, value: ".5", pos: 8},
+				{kind: tokenNumber, value: "0xff", pos: 11},
+				{kind: tokenNumber, value: "1e-3", pos: 16},
+				{kind: tokenNumber, value: "2E+10", pos: 21},
+				{kind: tokenEOF, pos: 26},
+			},
+		},
+		"Escaped quote": {
+			src: `'it''s'`,
+			tokens: []token{
+				{kind: tokenString, value: "it's", pos: 0},
+				{kind: tokenEOF, pos: 7},
+			},
+		},
+		"Identifiers": {
+			src: `steps.my-step.outputs._foo`,
+			tokens: []token{
+				{kind: tokenIdent, value: "steps", pos: 0},
+				{kind: tokenDot, value: ".", pos: 5},
+				{kind: tokenIdent, value: "my-step", pos: 6},
+				{kind: tokenDot, value: ".", pos: 13},
+				{kind: tokenIdent, value: "outputs", pos: 14},
+				{kind: tokenDot, value: ".", pos: 21},
+				{kind: tokenIdent, value: "_foo", pos: 22},
+				{kind: tokenEOF, pos: 26},
+			},
+		},
+		"Operators": {
+			src: `! != == < <= > >= && ||`,
+			tokens: []token{
+				{kind: tokenNot, value: "!", pos: 0},
+				{kind: tokenNotEq, value: "!=", pos: 2},
+				{kind: tokenEq, value: "==", pos: 5},
+				{kind: tokenLess, value: "<", pos: 8},
+				{kind: tokenLessEq, value: "<=", pos: 10},
+				{kind: tokenGreater, value: ">", pos: 13},
+				{kind: tokenGreaterEq, value: ">=", pos: 15},
+				{kind: tokenAnd, value: "&&", pos: 18},
+				{kind: tokenOr, value: "||", pos: 21},
+				{kind: tokenEOF, pos: 23},
+			},
+		},
+		"Punctuation": {
+			src: `f(a[*], b.*)`,
+			tokens: []token{
+				{kind: tokenIdent, value: "f", pos: 0},
+				{kind: tokenLeftParen, value: "(", pos: 1},
+				{kind: tokenIdent, value: "a", pos: 2},
+				{kind: tokenLeftBracket, value: "[", pos: 3},
+				{kind: tokenStar, value: "*", pos: 4},
+				{kind: tokenRightBracket, value: "]", pos: 5},
+				{kind: tokenComma, value: ",", pos: 6},
+				{kind: tokenIdent, value: "b", pos: 8},
+				{kind: tokenDot, value: ".", pos: 9},
+				{kind: tokenStar, value: "*", pos: 10},
+				{kind: tokenRightParen, value: ")", pos: 11},
+				{kind: tokenEOF, pos: 12},
+			},
+		},
+		"Whitespace": {
+			src: " \ta\n\r",
+			tokens: []token{
+				{kind: tokenIdent, value: "a", pos: 2},
+				{kind: tokenEOF, pos: 5},
+			},
+		},
+	}
+
+	for name, tt := range okCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := lex(tt.src)
+			if err != nil {
+				t.Fatalf("Want no error, got %v", err)
+			}
+
+			if got, want := len(got), len(tt.tokens); got != want {
+				t.Fatalf("Unexpected number of tokens (got %d, want %d)", got, want)
+			}
+
+			for i, want := range tt.tokens {
+				if got := got[i]; got != want {
+					t.Errorf("Unexpected token %d (got %+v, want %+v)", i, got, want)
+				}
+			}
+		})
+	}
+
+	errCases := map[string]TestCase{
+		"Double quoted string": {
+			src: `"foo"`,
+		},
+		"Unterminated string": {
+			src: `'foo`,
+		},
+		"Single equals": {
+			src: `a = b`,
+		},
+		"Single ampersand": {
+			src: `a & b`,
+		},
+		"Single pipe": {
+			src: `a | b`,
+		},
+		"Lone minus": {
+			src: `a - b`,
+		},
+		"Unknown character": {
+			src: `a # b`,
+		},
+	}
+
+	for name, tt := range errCases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := lex(tt.src); err == nil {
+				t.Error("Want an error, got none")
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Error is a problem in an expression.
+type Error struct {
+	// Pos is the byte offset of the problem in the expression.
+	Pos int
+
+	// Message describes the problem.
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Pos)
+}
+
+// Parse parses a single expression, without the surrounding `${{ }}`, into an
+// abstract syntax tree.
+func Parse(src string) (Node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{tokens: tokens}
+
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.unexpected(tok)
+	}
+
+	return n, nil
+}
+
+// parser is a recursive descent parser for expressions. Each method parses one
+// level of operator precedence, from lowest to highest.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+
+	return tok
+}
+
+func (p *parser) expect(kind tokenKind) (token, error) {
+	tok := p.next()
+	if tok.kind != kind {
+		return tok, &Error{
+			Pos:     tok.pos,
+			Message: fmt.Sprintf("expected %s, got %s", kind, tok.kind),
+		}
+	}
+
+	return tok, nil
+}
+
+func (p *parser) unexpected(tok token) error {
+	return &Error{Pos: tok.pos, Message: fmt.Sprintf("unexpected %s", tok.kind)}
+}
+
+// binary parses a left-associative sequence of operands separated by any of
+// the given operators.
+func (p *parser) binary(operand func() (Node, error), ops map[tokenKind]Operator) (Node, error) {
+	x, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+
+		op, ok := ops[tok.kind]
+		if !ok {
+			return x, nil
+		}
+
+		p.next()
+
+		y, err := operand()
+		if err != nil {
+			return nil, err
+		}
+
+		x = &Binary{X: x, Op: op, Y: y, OpOffset: tok.pos}
+	}
+}
+
+func (p *parser) or() (Node, error) {
+	return p.binary(p.and, map[tokenKind]Operator{tokenOr: OpOr})
+}
+
+func (p *parser) and() (Node, error) {
+	return p.binary(p.equality, map[tokenKind]Operator{tokenAnd: OpAnd})
+}
+
+func (p *parser) equality() (Node, error) {
+	return p.binary(p.comparison, map[tokenKind]Operator{
+		tokenEq:    OpEq,
+		tokenNotEq: OpNotEq,
+	})
+}
+
+func (p *parser) comparison() (Node, error) {
+	return p.binary(p.unary, map[tokenKind]Operator{
+		tokenLess:      OpLess,
+		tokenLessEq:    OpLessEq,
+		tokenGreater:   OpGreater,
+		tokenGreaterEq: OpGreaterEq,
+	})
+}
+
+func (p *parser) unary() (Node, error) {
+	if tok := p.peek(); tok.kind == tokenNot {
+		p.next()
+
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &Not{Offset: tok.pos, X: x}, nil
+	}
+
+	return p.postfix()
+}
+
+func (p *parser) postfix() (Node, error) {
+	x, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch p.peek().kind {
+		case tokenDot:
+			p.next()
+
+			switch tok := p.next(); tok.kind {
+			case tokenStar:
+				x = &Filter{X: x}
+			case tokenIdent, tokenNull, tokenBool:
+				x = &Property{X: x, Name: tok.value}
+			default:
+				return nil, &Error{
+					Pos:     tok.pos,
+					Message: fmt.Sprintf("expected property name, got %s", tok.kind),
+				}
+			}
+		case tokenLeftBracket:
+			p.next()
+
+			if p.peek().kind == tokenStar {
+				p.next()
+				x = &Filter{X: x}
+			} else {
+				index, err := p.or()
+				if err != nil {
+					return nil, err
+				}
+
+				x = &Index{X: x, Index: index}
+			}
+
+			if _, err := p.expect(tokenRightBracket); err != nil {
+				return nil, err
+			}
+		default:
+			return x, nil
+		}
+	}
+}
+
+func (p *parser) primary() (Node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNull:
+		return &Null{Offset: tok.pos}, nil
+	case tokenBool:
+		return &Bool{Offset: tok.pos, Value: tok.value == "true"}, nil
+	case tokenNumber:
+		v, err := parseNumber(tok.value)
+		if err != nil {
+			return nil, &Error{Pos: tok.pos, Message: fmt.Sprintf("invalid number %q", tok.value)}
+		}
+
+		return &Number{Offset: tok.pos, Value: v, Raw: tok.value}, nil
+	case tokenString:
+		return &String{Offset: tok.pos, Value: tok.value}, nil
+	case tokenIdent:
+		if p.peek().kind == tokenLeftParen {
+			return p.call(tok)
+		}
+
+		return &Ident{Offset: tok.pos, Name: tok.value}, nil
+	case tokenLeftParen:
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := p.expect(tokenRightParen); err != nil {
+			return nil, err
+		}
+
+		return x, nil
+	default:
+		return nil, p.unexpected(tok)
+	}
+}
+
+func (p *parser) call(name token) (Node, error) {
+	p.next() // (
+
+	call := &Call{Offset: name.pos, Name: name.value}
+	if p.peek().kind == tokenRightParen {
+		p.next()
+		return call, nil
+	}
+
+	for {
+		arg, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+
+		call.Args = append(call.Args, arg)
+
+		switch tok := p.next(); tok.kind {
+		case tokenComma:
+		case tokenRightParen:
+			return call, nil
+		default:
+			return nil, &Error{
+				Pos:     tok.pos,
+				Message: fmt.Sprintf("expected ',' or ')', got %s", tok.kind),
+			}
+		}
+	}
+}
+
+// parseNumber parses a number literal, which is either a decimal number (with
+// an optional fraction and exponent) or a hexadecimal integer.
+func parseNumber(s string) (float64, error) {
+	digits := strings.TrimPrefix(s, "-")
+	if strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X") {
+		v, err := strconv.ParseUint(digits[2:], 16, 64)
+		if err != nil {
+			return 0, err
+		}
+
+		if digits != s {
+			return -float64(v), nil
+		}
+
+		return float64(v), nil
+	}
+
+	if strings.ContainsAny(s, "_xXpP") {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+
+	return strconv.ParseFloat(s, 64)
+}
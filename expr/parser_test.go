@@ -0,0 +1,313 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package expr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	type TestCase struct {
+		src  string
+		want string
+	}
+
+	okCases := map[string]TestCase{
+		"null": {
+			src:  `null`,
+			want: `null`,
+		},
+		"Boolean": {
+			src:  `true`,
+			want: `true`,
+		},
+		"Number": {
+			src:  `-3.14`,
+			want: `-3.14`,
+		},
+		"String": {
+			src:  `'it''s'`,
+			want: `'it''s'`,
+		},
+		"Context": {
+			src:  `github`,
+			want: `github`,
+		},
+		"Property": {
+			src:  `github.event.pull_request.title`,
+			want: `github.event.pull_request.title`,
+		},
+		"Keyword as property": {
+			src:  `inputs.true`,
+			want: `inputs.true`,
+		},
+		"Index": {
+			src:  `github['event'].commits[0]`,
+			want: `github['event'].commits[0]`,
+		},
+		"Expression as index": {
+			src:  `matrix[inputs.key || 'os']`,
+			want: `matrix[(inputs.key || 'os')]`,
+		},
+		"Property filter": {
+			src:  `github.event.commits.*.author`,
+			want: `github.event.commits.*.author`,
+		},
+		"Index filter": {
+			src:  `needs[*].result`,
+			want: `needs.*.result`,
+		},
+		"Function call": {
+			src:  `contains(github.event.issue.labels.*.name, 'bug')`,
+			want: `contains(github.event.issue.labels.*.name, 'bug')`,
+		},
+		"Function call without arguments": {
+			src:  `success()`,
+			want: `success()`,
+		},
+		"Property of function call": {
+			src:  `fromJSON(steps.meta.outputs.json).tags[0]`,
+			want: `fromJSON(steps.meta.outputs.json).tags[0]`,
+		},
+		"Nested function calls": {
+			src:  `format('{0}-{1}', runner.os, hashFiles('**/go.sum'))`,
+			want: `format('{0}-{1}', runner.os, hashFiles('**/go.sum'))`,
+		},
+		"Not": {
+			src:  `!!cancelled()`,
+			want: `!!cancelled()`,
+		},
+		"Comparison": {
+			src:  `github.run_attempt >= 2`,
+			want: `(github.run_attempt >= 2)`,
+		},
+		"Precedence": {
+			src:  `a || b && c == d < e`,
+			want: `(a || (b && (c == (d < e))))`,
+		},
+		"Left associativity": {
+			src:  `a && b && c`,
+			want: `((a && b) && c)`,
+		},
+		"Not binds tighter than comparison": {
+			src:  `!a == b`,
+			want: `(!a == b)`,
+		},
+		"Parentheses": {
+			src:  `(a || b) && !(c != d)`,
+			want: `((a || b) && !(c != d))`,
+		},
+		"Whitespace": {
+			src:  "\n  github.ref\n  == 'refs/heads/main'\n",
+			want: `(github.ref == 'refs/heads/main')`,
+		},
+	}
+
+	for name, tt := range okCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Want no error, got %v", err)
+			}
+
+			if got, want := got.String(), tt.want; got != want {
+				t.Errorf("Unexpected expression (got %q, want %q)", got, want)
+			}
+		})
+	}
+
+	type ErrCase struct {
+		src string
+		pos int
+	}
+
+	errCases := map[string]ErrCase{
+		"Empty": {
+			src: ``,
+			pos: 0,
+		},
+		"Lexical error": {
+			src: `a = b`,
+			pos: 2,
+		},
+		"Trailing tokens": {
+			src: `a b`,
+			pos: 2,
+		},
+		"Missing operand": {
+			src: `a ==`,
+			pos: 4,
+		},
+		"Missing property name": {
+			src: `github.`,
+			pos: 7,
+		},
+		"Invalid property name": {
+			src: `github.'event'`,
+			pos: 7,
+		},
+		"Unclosed index": {
+			src: `github['event'`,
+			pos: 14,
+		},
+		"Unclosed parenthesis": {
+			src: `(a || b`,
+			pos: 7,
+		},
+		"Unclosed call": {
+			src: `contains(a, b`,
+			pos: 13,
+		},
+		"Trailing comma": {
+			src: `contains(a, )`,
+			pos: 12,
+		},
+		"Call of non-identifier": {
+			src: `github.contains(a)`,
+			pos: 15,
+		},
+		"Invalid number": {
+			src: `12abc`,
+			pos: 0,
+		},
+		"Invalid hexadecimal number": {
+			src: `0xfg`,
+			pos: 0,
+		},
+	}
+
+	for name, tt := range errCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(tt.src)
+			if err == nil {
+				t.Fatal("Want an error, got none")
+			}
+
+			var got *Error
+			if !errors.As(err, &got) {
+				t.Fatalf("Want an Error, got %#v", err)
+			}
+
+			if got, want := got.Pos, tt.pos; got != want {
+				t.Errorf("Unexpected error position (got %d, want %d)", got, want)
+			}
+		})
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	type TestCase struct {
+		src  string
+		want float64
+	}
+
+	testCases := map[string]TestCase{
+		"Integer": {
+			src:  `42`,
+			want: 42,
+		},
+		"Negative": {
+			src:  `-42`,
+			want: -42,
+		},
+		"Fraction": {
+			src:  `.5`,
+			want: 0.5,
+		},
+		"Exponent": {
+			src:  `1e3`,
+			want: 1000,
+		},
+		"Hexadecimal": {
+			src:  `0xff`,
+			want: 255,
+		},
+		"Negative hexadecimal": {
+			src:  `-0x10`,
+			want: -16,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			n, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Want no error, got %v", err)
+			}
+
+			number, ok := n.(*Number)
+			if !ok {
+				t.Fatalf("Want a Number, got %T", n)
+			}
+
+			if got, want := number.Value, tt.want; got != want {
+				t.Errorf("Unexpected value (got %v, want %v)", got, want)
+			}
+		})
+	}
+}
+
+func TestParsePositions(t *testing.T) {
+	src := `contains(github.event.labels.*.name, 'bug') && !failure()`
+
+	n, err := Parse(src)
+	if err != nil {
+		t.Fatalf("Want no error, got %v", err)
+	}
+
+	and, ok := n.(*Binary)
+	if !ok {
+		t.Fatalf("Want a Binary, got %T", n)
+	}
+
+	call := and.X.(*Call)
+	not := and.Y.(*Not)
+
+	type TestCase struct {
+		got  int
+		want int
+	}
+
+	testCases := map[string]TestCase{
+		"binary":          {got: and.Pos(), want: 0},
+		"operator":        {got: and.OpOffset, want: 44},
+		"call":            {got: call.Pos(), want: 0},
+		"property filter": {got: call.Args[0].Pos(), want: 9},
+		"string":          {got: call.Args[1].Pos(), want: 37},
+		"not":             {got: not.Pos(), want: 47},
+		"negated call":    {got: not.X.Pos(), want: 48},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got, want := tt.got, tt.want; got != want {
+				t.Errorf("Unexpected position (got %d, want %d)", got, want)
+			}
+		})
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	seeds := []string{
+		`github.event_name == 'push' && !cancelled()`,
+		`contains(fromJSON('["a", "b"]'), matrix.os[0])`,
+		`needs[*].result`,
+		`format('{0}', -0x1F) || 1e-3`,
+	}
+
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, src string) {
+		n, err := Parse(src)
+		if err != nil {
+			return
+		}
+
+		if _, err := Parse(n.String()); err != nil {
+			t.Errorf("Could not parse %q, printed from %q: %v", n.String(), src, err)
+		}
+	})
+}
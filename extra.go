@@ -0,0 +1,13 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package gha
+
+import (
+	"go.yaml.in/yaml/v3"
+)
+
+// Extra holds the keys of an object that are not part of its model, such as
+// keys GitHub introduced after this library was last updated or extensions
+// used by other platforms. They are kept as raw YAML so that they survive
+// marshalling.
+type Extra map[string]yaml.Node
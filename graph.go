@@ -0,0 +1,244 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package gha
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// JobGraph is the dependency graph of the jobs of a [Workflow], as defined by
+// their `needs:`. Job ids are case insensitive, as on GitHub, and are returned
+// as they are declared in the workflow's `jobs:`, in lexical order where the
+// order is not otherwise determined.
+type JobGraph struct {
+	// needs are the jobs that each job directly depends on.
+	needs map[string][]string
+
+	// dependents are the jobs that directly depend on each job.
+	dependents map[string][]string
+}
+
+// JobGraph returns the dependency graph of the jobs of the workflow. It returns
+// [Errors] if a job needs a job that does not exist or if jobs depend on each
+// other in a cycle. In that case the graph is still returned, without the
+// dependencies on jobs that do not exist.
+func (w *Workflow) JobGraph() (*JobGraph, error) {
+	g := &JobGraph{
+		needs:      make(map[string][]string, len(w.Jobs)),
+		dependents: make(map[string][]string, len(w.Jobs)),
+	}
+
+	var errs Errors
+	for _, id := range slices.Sorted(maps.Keys(w.Jobs)) {
+		job := w.Jobs[id]
+
+		g.needs[id] = []string{}
+		for _, name := range job.Needs {
+			need, ok := w.jobId(name)
+			if !ok {
+				errs = append(errs, w.needsError(id, "unknown job %q", name))
+				continue
+			}
+
+			if !slices.Contains(g.needs[id], need) {
+				g.needs[id] = append(g.needs[id], need)
+				g.dependents[need] = append(g.dependents[need], id)
+			}
+		}
+	}
+
+	for id := range g.needs {
+		slices.Sort(g.needs[id])
+		slices.Sort(g.dependents[id])
+	}
+
+	for _, cycle := range g.cycles() {
+		errs = append(errs, w.needsError(cycle[0], "dependency cycle %s", strings.Join(cycle, " -> ")))
+	}
+
+	if len(errs) != 0 {
+		sortErrors(errs)
+		return g, errs
+	}
+
+	return g, nil
+}
+
+// jobId returns the id of the job in the workflow that matches id, which is
+// case insensitive. An exact match takes precedence.
+func (w *Workflow) jobId(id string) (string, bool) {
+	if _, ok := w.Jobs[id]; ok {
+		return id, true
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(w.Jobs)) {
+		if strings.EqualFold(name, id) {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// needsError returns an error for the `needs:` of the job id.
+func (w *Workflow) needsError(id, format string, a ...any) *Error {
+	job := w.Jobs[id]
+
+	pos, ok := job.Positions["needs"]
+	if !ok {
+		pos = job.Position
+	}
+
+	return &Error{
+		Path:    "jobs." + id + ".needs",
+		Line:    pos.Line,
+		Column:  pos.Column,
+		Message: fmt.Sprintf(format, a...),
+	}
+}
+
+// cycles returns the cycles in the graph, each as a path of job ids that starts
+// and ends with the same job, e.g. ["a", "b", "a"] if job a needs job b and b
+// needs a.
+func (g *JobGraph) cycles() [][]string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	var (
+		result [][]string
+		path   []string
+		state  = make(map[string]int, len(g.needs))
+	)
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		path = append(path, id)
+
+		for _, need := range g.needs[id] {
+			switch state[need] {
+			case unvisited:
+				visit(need)
+			case visiting:
+				start := slices.Index(path, need)
+				result = append(result, append(slices.Clone(path[start:]), need))
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[id] = visited
+	}
+
+	for _, id := range g.Jobs() {
+		if state[id] == unvisited {
+			visit(id)
+		}
+	}
+
+	return result
+}
+
+// Jobs returns the ids of all jobs in the graph.
+func (g *JobGraph) Jobs() []string {
+	return slices.Sorted(maps.Keys(g.needs))
+}
+
+// Order returns the ids of the jobs in an order in which they can run, that is
+// every job comes after the jobs it needs. Jobs that are part of, or depend on,
+// a dependency cycle are omitted.
+func (g *JobGraph) Order() []string {
+	return slices.Concat(g.Waves()...)
+}
+
+// Waves returns the ids of the jobs grouped in the order in which they can run.
+// The jobs in a wave only need jobs in earlier waves, so they can run in
+// parallel once those have completed. Jobs that are part of, or depend on, a
+// dependency cycle are omitted.
+func (g *JobGraph) Waves() [][]string {
+	remaining := make(map[string]int, len(g.needs))
+	for id, needs := range g.needs {
+		remaining[id] = len(needs)
+	}
+
+	var wave []string
+	for _, id := range g.Jobs() {
+		if remaining[id] == 0 {
+			wave = append(wave, id)
+		}
+	}
+
+	result := [][]string{}
+	for len(wave) != 0 {
+		result = append(result, wave)
+
+		var next []string
+		for _, id := range wave {
+			for _, dependent := range g.dependents[id] {
+				remaining[dependent]--
+				if remaining[dependent] == 0 {
+					next = append(next, dependent)
+				}
+			}
+		}
+
+		slices.Sort(next)
+		wave = next
+	}
+
+	return result
+}
+
+// Upstream returns the ids of the jobs that the job id needs, directly or
+// indirectly.
+func (g *JobGraph) Upstream(id string) []string {
+	return reachable(g.needs, g.jobId(id))
+}
+
+// Downstream returns the ids of the jobs that need the job id, directly or
+// indirectly.
+func (g *JobGraph) Downstream(id string) []string {
+	return reachable(g.dependents, g.jobId(id))
+}
+
+// jobId returns the id of the job in the graph that matches id, which is case
+// insensitive, or id itself if there is none.
+func (g *JobGraph) jobId(id string) string {
+	if _, ok := g.needs[id]; ok {
+		return id
+	}
+
+	for _, name := range g.Jobs() {
+		if strings.EqualFold(name, id) {
+			return name
+		}
+	}
+
+	return id
+}
+
+// reachable returns the ids that can be reached from id following edges,
+// excluding id itself unless it is part of a cycle.
+func reachable(edges map[string][]string, id string) []string {
+	seen := make(map[string]bool)
+
+	queue := slices.Clone(edges[id])
+	for len(queue) != 0 {
+		next := queue[0]
+		queue = queue[1:]
+
+		if seen[next] {
+			continue
+		}
+
+		seen[next] = true
+		queue = append(queue, edges[next]...)
+	}
+
+	return slices.Sorted(maps.Keys(seen))
+}
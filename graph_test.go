@@ -0,0 +1,272 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package gha
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWorkflowJobGraph(t *testing.T) {
+	type TestCase struct {
+		yaml  string
+		order []string
+		waves [][]string
+		want  Errors
+	}
+
+	testCases := map[string]TestCase{
+		"No jobs": {
+			yaml:  `on: push`,
+			order: nil,
+			waves: [][]string{},
+			want:  nil,
+		},
+		"Independent jobs": {
+			yaml: `on: push
+jobs:
+  lint:
+    runs-on: ubuntu-latest
+  build:
+    runs-on: ubuntu-latest
+`,
+			order: []string{"build", "lint"},
+			waves: [][]string{{"build", "lint"}},
+			want:  nil,
+		},
+		"Dependencies": {
+			yaml: `on: push
+jobs:
+  deploy:
+    needs: [test, lint]
+    runs-on: ubuntu-latest
+  test:
+    needs: build
+    runs-on: ubuntu-latest
+  lint:
+    runs-on: ubuntu-latest
+  build:
+    runs-on: ubuntu-latest
+  docs:
+    needs: [build, build]
+    runs-on: ubuntu-latest
+`,
+			order: []string{"build", "lint", "docs", "test", "deploy"},
+			waves: [][]string{{"build", "lint"}, {"docs", "test"}, {"deploy"}},
+			want:  nil,
+		},
+		"Case insensitive": {
+			yaml: `on: push
+jobs:
+  test:
+    needs: [build, LINT]
+    runs-on: ubuntu-latest
+  Build:
+    runs-on: ubuntu-latest
+  Lint:
+    runs-on: ubuntu-latest
+`,
+			order: []string{"Build", "Lint", "test"},
+			waves: [][]string{{"Build", "Lint"}, {"test"}},
+			want:  nil,
+		},
+		"Unknown job": {
+			yaml: `on: push
+jobs:
+  test:
+    needs: [build, setup]
+    runs-on: ubuntu-latest
+  build:
+    runs-on: ubuntu-latest
+`,
+			order: []string{"build", "test"},
+			waves: [][]string{{"build"}, {"test"}},
+			want: Errors{
+				{
+					Path:    "jobs.test.needs",
+					Line:    4,
+					Column:  5,
+					Message: `unknown job "setup"`,
+				},
+			},
+		},
+		"Cycle": {
+			yaml: `on: push
+jobs:
+  a:
+    needs: c
+    runs-on: ubuntu-latest
+  b:
+    needs: a
+    runs-on: ubuntu-latest
+  c:
+    needs: b
+    runs-on: ubuntu-latest
+  d:
+    needs: c
+    runs-on: ubuntu-latest
+  e:
+    runs-on: ubuntu-latest
+`,
+			order: []string{"e"},
+			waves: [][]string{{"e"}},
+			want: Errors{
+				{
+					Path:    "jobs.a.needs",
+					Line:    4,
+					Column:  5,
+					Message: `dependency cycle a -> c -> b -> a`,
+				},
+			},
+		},
+		"Self dependency": {
+			yaml: `on: push
+jobs:
+  build:
+    needs: build
+    runs-on: ubuntu-latest
+`,
+			order: nil,
+			waves: [][]string{},
+			want: Errors{
+				{
+					Path:    "jobs.build.needs",
+					Line:    4,
+					Column:  5,
+					Message: `dependency cycle build -> build`,
+				},
+			},
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			workflow, err := ParseWorkflow([]byte(tt.yaml))
+			if err != nil {
+				t.Fatalf("Want no error, got %#v", err)
+			}
+
+			graph, err := workflow.JobGraph()
+			if tt.want == nil && err != nil {
+				t.Fatalf("Want no error, got %v", err)
+			} else if tt.want != nil {
+				var errs Errors
+				if !errors.As(err, &errs) {
+					t.Fatalf("Want Errors, got %#v", err)
+				}
+
+				checkErrors(t, errs, tt.want)
+			}
+
+			if got, want := graph.Order(), tt.order; !reflect.DeepEqual(got, want) {
+				t.Errorf("Unexpected order (got %q, want %q)", got, want)
+			}
+
+			if got, want := graph.Waves(), tt.waves; !reflect.DeepEqual(got, want) {
+				t.Errorf("Unexpected waves (got %q, want %q)", got, want)
+			}
+		})
+	}
+}
+
+func TestJobGraphUpstreamDownstream(t *testing.T) {
+	yaml := `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+  lint:
+    runs-on: ubuntu-latest
+  test:
+    needs: build
+    runs-on: ubuntu-latest
+  deploy:
+    needs: [test, lint]
+    runs-on: ubuntu-latest
+  notify:
+    needs: deploy
+    runs-on: ubuntu-latest
+`
+
+	workflow, err := ParseWorkflow([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Want no error, got %#v", err)
+	}
+
+	graph, err := workflow.JobGraph()
+	if err != nil {
+		t.Fatalf("Want no error, got %v", err)
+	}
+
+	if got, want := graph.Jobs(), []string{"build", "deploy", "lint", "notify", "test"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected jobs (got %q, want %q)", got, want)
+	}
+
+	type TestCase struct {
+		id         string
+		upstream   []string
+		downstream []string
+	}
+
+	testCases := map[string]TestCase{
+		"Root": {
+			id:         "build",
+			upstream:   nil,
+			downstream: []string{"deploy", "notify", "test"},
+		},
+		"Middle": {
+			id:         "deploy",
+			upstream:   []string{"build", "lint", "test"},
+			downstream: []string{"notify"},
+		},
+		"Leaf": {
+			id:         "notify",
+			upstream:   []string{"build", "deploy", "lint", "test"},
+			downstream: nil,
+		},
+		"Case insensitive": {
+			id:         "Deploy",
+			upstream:   []string{"build", "lint", "test"},
+			downstream: []string{"notify"},
+		},
+		"Unknown job": {
+			id:         "release",
+			upstream:   nil,
+			downstream: nil,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got, want := graph.Upstream(tt.id), tt.upstream; !reflect.DeepEqual(got, want) {
+				t.Errorf("Unexpected upstream (got %q, want %q)", got, want)
+			}
+
+			if got, want := graph.Downstream(tt.id), tt.downstream; !reflect.DeepEqual(got, want) {
+				t.Errorf("Unexpected downstream (got %q, want %q)", got, want)
+			}
+		})
+	}
+}
+
+func TestJobGraphCycleUpstream(t *testing.T) {
+	yaml := `on: push
+jobs:
+  a:
+    needs: b
+    runs-on: ubuntu-latest
+  b:
+    needs: a
+    runs-on: ubuntu-latest
+`
+
+	workflow, err := ParseWorkflow([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Want no error, got %#v", err)
+	}
+
+	graph, _ := workflow.JobGraph()
+	if got, want := graph.Upstream("a"), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected upstream (got %q, want %q)", got, want)
+	}
+}
@@ -19,12 +19,34 @@ type Manifest struct {
 	Outputs map[string]Output `yaml:"outputs,omitempty"`
 
 	Runs Runs `yaml:"runs"`
+
+	Extra Extra `yaml:",inline"`
+
+	// Position is the position of the manifest in the YAML document.
+	Position Position `yaml:"-"`
+
+	// Positions are the positions of the keys of the manifest, e.g. "runs.using".
+	Positions Positions `yaml:"-"`
+}
+
+func (m *Manifest) UnmarshalYAML(n *yaml.Node) error {
+	type manifest Manifest
+	if err := n.Decode((*manifest)(m)); err != nil {
+		return err
+	}
+
+	m.Position = position(n)
+	m.Positions = positions(n)
+
+	return nil
 }
 
 // Branding is a model of an Action [Manifest]'s `branding:` object.
 type Branding struct {
 	Color string `yaml:"color"`
 	Icon  string `yaml:"icon"`
+
+	Extra Extra `yaml:",inline"`
 }
 
 // Input is a model of an Action [Manifest]'s `inputs:`.
@@ -33,12 +55,16 @@ type Input struct {
 	Default            string `yaml:"default,omitempty"`
 	Required           bool   `yaml:"required,omitempty"`
 	DeprecationMessage string `yaml:"deprecationMessage,omitempty"`
+
+	Extra Extra `yaml:",inline"`
 }
 
 // Output is a model of an Action [Manifest]'s `outputs:`.
 type Output struct {
 	Description string `yaml:"description"`
 	Value       string `yaml:"value"`
+
+	Extra Extra `yaml:",inline"`
 }
 
 // Runs is a model of an Action [Manifest]'s `runs:` object.
@@ -65,13 +91,41 @@ type Runs struct {
 	Main   string `yaml:"main,omitempty"`
 	Post   string `yaml:"post,omitempty"`
 	PostIf string `yaml:"post-if,omitempty"`
+
+	Extra Extra `yaml:",inline"`
+}
+
+// Bytes returns the YAML representation of the manifest.
+func (m *Manifest) Bytes() ([]byte, error) {
+	data, err := marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal manifest: %v", err)
+	}
+
+	return data, nil
 }
 
 // ParseManifest parses a GitHub Actions Action manifest into a [Manifest].
 func ParseManifest(data []byte) (Manifest, error) {
+	return ParseManifestWithOptions(data)
+}
+
+// ParseManifestWithOptions parses a GitHub Actions Action manifest into a [Manifest] using the
+// given options.
+func ParseManifestWithOptions(data []byte, opts ...ParseOption) (Manifest, error) {
+	var options parseOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var s *schema
+	if options.strict {
+		s = manifestSchema
+	}
+
 	var manifest Manifest
-	if err := yaml.Unmarshal(data, &manifest); err != nil {
-		return manifest, fmt.Errorf("could not parse manifest: %v", err)
+	if err := unmarshal(data, &manifest, s); err != nil {
+		return manifest, fmt.Errorf("could not parse manifest: %w", err)
 	}
 
 	return manifest, nil
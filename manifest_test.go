@@ -92,8 +92,8 @@ runs:
 								Name: "actions/checkout",
 								Ref:  "v3",
 							},
-							With: map[string]string{
-								"fetch-depth": "1",
+							With: map[string]Value{
+								"fetch-depth": {Raw: "1"},
 							},
 						},
 						{
@@ -107,8 +107,8 @@ runs:
 								Name: "actions/github-script",
 								Ref:  "v6",
 							},
-							With: map[string]string{
-								"script": "console.log('${{ inputs.value }}')",
+							With: map[string]Value{
+								"script": {Raw: "console.log('${{ inputs.value }}')"},
 							},
 						},
 					},
@@ -450,6 +450,109 @@ runs:
 	}
 }
 
+func TestManifestBytes(t *testing.T) {
+	type TestCase struct {
+		model Manifest
+		yaml  string
+	}
+
+	testCases := map[string]TestCase{
+		"Composite manifest": {
+			model: Manifest{
+				Name:        "Composite action example",
+				Description: "An example of a composite action",
+				Inputs: map[string]Input{
+					"value": {
+						Description: "The value to echo",
+						Required:    true,
+					},
+				},
+				Outputs: map[string]Output{
+					"time": {
+						Description: "The time",
+						Value:       "${{ steps.time.outputs.time }}",
+					},
+				},
+				Runs: Runs{
+					Using: "composite",
+					Steps: []Step{
+						{
+							Uses: Uses{
+								Name: "actions/checkout",
+								Ref:  "v4",
+							},
+						},
+						{
+							Shell: "bash",
+							Run:   "echo '${{ inputs.value }}'",
+						},
+					},
+				},
+			},
+			yaml: `name: Composite action example
+description: An example of a composite action
+inputs:
+  value:
+    description: The value to echo
+    required: true
+outputs:
+  time:
+    description: The time
+    value: ${{ steps.time.outputs.time }}
+runs:
+  using: composite
+  steps:
+    - uses: actions/checkout@v4
+    - shell: bash
+      run: echo '${{ inputs.value }}'
+`,
+		},
+		"Node manifest": {
+			model: Manifest{
+				Name:        "Node action example",
+				Description: "An example of a Node action",
+				Branding: Branding{
+					Color: "black",
+					Icon:  "coffee",
+				},
+				Runs: Runs{
+					Using: "node20",
+					Main:  "index.js",
+				},
+			},
+			yaml: `name: Node action example
+description: An example of a Node action
+branding:
+  color: black
+  icon: coffee
+runs:
+  using: node20
+  main: index.js
+`,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := tt.model.Bytes()
+			if err != nil {
+				t.Fatalf("Want no error, got %#v", err)
+			}
+
+			if got, want := string(got), tt.yaml; got != want {
+				t.Errorf("Unexpected YAML\ngot:\n%s\nwant:\n%s", got, want)
+			}
+
+			manifest, err := ParseManifest(got)
+			if err != nil {
+				t.Fatalf("Want no error on parse, got %#v", err)
+			}
+
+			checkManifest(t, &manifest, &tt.model)
+		})
+	}
+}
+
 func FuzzParseManifest(f *testing.F) {
 	seeds := []string{
 		`
@@ -665,3 +768,44 @@ func checkRuns(t *testing.T, got, want *Runs) {
 		t.Errorf("Unexpected runs post-if (got %q, want %q)", got, want)
 	}
 }
+
+func TestManifestExtra(t *testing.T) {
+	yaml := `name: Example
+description: An example
+inputs:
+  value:
+    description: A value
+    future-key: true
+runs:
+  using: node24
+  main: index.js
+  future-key: [a, b]
+future-key: value
+`
+
+	manifest, err := ParseManifest([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Want no error, got %#v", err)
+	}
+
+	if got, want := len(manifest.Extra), 1; got != want {
+		t.Errorf("Unexpected number of extra keys (got %d, want %d)", got, want)
+	}
+
+	if got, want := len(manifest.Inputs["value"].Extra), 1; got != want {
+		t.Errorf("Unexpected number of extra input keys (got %d, want %d)", got, want)
+	}
+
+	if got, want := len(manifest.Runs.Extra), 1; got != want {
+		t.Errorf("Unexpected number of extra runs keys (got %d, want %d)", got, want)
+	}
+
+	got, err := manifest.Bytes()
+	if err != nil {
+		t.Fatalf("Want no error, got %#v", err)
+	}
+
+	if got, want := string(got), yaml; got != want {
+		t.Errorf("Unexpected YAML\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
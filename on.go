@@ -0,0 +1,458 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package gha
+
+import (
+	"go.yaml.in/yaml/v3"
+)
+
+// On is a model of a GitHub Actions workflow `on:` object.
+//
+// An event is configured if its field is non-nil. Events without configuration
+// (e.g. `on: push` or `on: { push: ~ }`) are represented by a zero value.
+type On struct {
+	BranchProtectionRule     *Event                 `yaml:"branch_protection_rule,omitempty"`
+	CheckRun                 *Event                 `yaml:"check_run,omitempty"`
+	CheckSuite               *Event                 `yaml:"check_suite,omitempty"`
+	Create                   *Event                 `yaml:"create,omitempty"`
+	Delete                   *Event                 `yaml:"delete,omitempty"`
+	Deployment               *Event                 `yaml:"deployment,omitempty"`
+	DeploymentStatus         *Event                 `yaml:"deployment_status,omitempty"`
+	Discussion               *Event                 `yaml:"discussion,omitempty"`
+	DiscussionComment        *Event                 `yaml:"discussion_comment,omitempty"`
+	Fork                     *Event                 `yaml:"fork,omitempty"`
+	Gollum                   *Event                 `yaml:"gollum,omitempty"`
+	IssueComment             *Event                 `yaml:"issue_comment,omitempty"`
+	Issues                   *Event                 `yaml:"issues,omitempty"`
+	Label                    *Event                 `yaml:"label,omitempty"`
+	MergeGroup               *Event                 `yaml:"merge_group,omitempty"`
+	Milestone                *Event                 `yaml:"milestone,omitempty"`
+	PageBuild                *Event                 `yaml:"page_build,omitempty"`
+	Public                   *Event                 `yaml:"public,omitempty"`
+	PullRequest              *PullRequestEvent      `yaml:"pull_request,omitempty"`
+	PullRequestReview        *Event                 `yaml:"pull_request_review,omitempty"`
+	PullRequestReviewComment *Event                 `yaml:"pull_request_review_comment,omitempty"`
+	PullRequestTarget        *PullRequestEvent      `yaml:"pull_request_target,omitempty"`
+	Push                     *PushEvent             `yaml:"push,omitempty"`
+	RegistryPackage          *Event                 `yaml:"registry_package,omitempty"`
+	Release                  *Event                 `yaml:"release,omitempty"`
+	RepositoryDispatch       *Event                 `yaml:"repository_dispatch,omitempty"`
+	Schedule                 []Schedule             `yaml:"schedule,omitempty"`
+	Status                   *Event                 `yaml:"status,omitempty"`
+	Watch                    *Event                 `yaml:"watch,omitempty"`
+	WorkflowCall             *WorkflowCallEvent     `yaml:"workflow_call,omitempty"`
+	WorkflowDispatch         *WorkflowDispatchEvent `yaml:"workflow_dispatch,omitempty"`
+	WorkflowRun              *WorkflowRunEvent      `yaml:"workflow_run,omitempty"`
+
+	Extra Extra `yaml:",inline"`
+}
+
+// Event is a model of a GitHub Actions workflow event that can only be
+// filtered by activity type.
+type Event struct {
+	Types Types `yaml:"types,omitempty"`
+
+	Extra Extra `yaml:",inline"`
+}
+
+// PullRequestEvent is a model of a GitHub Actions `pull_request:` or
+// `pull_request_target:` event.
+type PullRequestEvent struct {
+	Types          Types    `yaml:"types,omitempty"`
+	Branches       []string `yaml:"branches,omitempty"`
+	BranchesIgnore []string `yaml:"branches-ignore,omitempty"`
+	Paths          []string `yaml:"paths,omitempty"`
+	PathsIgnore    []string `yaml:"paths-ignore,omitempty"`
+
+	Extra Extra `yaml:",inline"`
+}
+
+// PushEvent is a model of a GitHub Actions `push:` event.
+type PushEvent struct {
+	Branches       []string `yaml:"branches,omitempty"`
+	BranchesIgnore []string `yaml:"branches-ignore,omitempty"`
+	Tags           []string `yaml:"tags,omitempty"`
+	TagsIgnore     []string `yaml:"tags-ignore,omitempty"`
+	Paths          []string `yaml:"paths,omitempty"`
+	PathsIgnore    []string `yaml:"paths-ignore,omitempty"`
+
+	Extra Extra `yaml:",inline"`
+}
+
+// Schedule is a model of a GitHub Actions `schedule:` entry.
+type Schedule struct {
+	Cron string `yaml:"cron"`
+
+	Extra Extra `yaml:",inline"`
+}
+
+// WorkflowCallEvent is a model of a GitHub Actions `workflow_call:` event.
+type WorkflowCallEvent struct {
+	Inputs  map[string]WorkflowCallInput  `yaml:"inputs,omitempty"`
+	Outputs map[string]Output             `yaml:"outputs,omitempty"`
+	Secrets map[string]WorkflowCallSecret `yaml:"secrets,omitempty"`
+
+	Extra Extra `yaml:",inline"`
+}
+
+// WorkflowCallInput is a model of a [WorkflowCallEvent]'s `inputs:`.
+type WorkflowCallInput struct {
+	Description string `yaml:"description,omitempty"`
+	Required    bool   `yaml:"required,omitempty"`
+	Default     string `yaml:"default,omitempty"`
+
+	// Type is the type of the input, one of "string", "boolean", or "number".
+	Type string `yaml:"type"`
+
+	Extra Extra `yaml:",inline"`
+}
+
+// WorkflowCallSecret is a model of a [WorkflowCallEvent]'s `secrets:`.
+type WorkflowCallSecret struct {
+	Description string `yaml:"description,omitempty"`
+	Required    bool   `yaml:"required,omitempty"`
+
+	Extra Extra `yaml:",inline"`
+}
+
+// WorkflowDispatchEvent is a model of a GitHub Actions `workflow_dispatch:` event.
+type WorkflowDispatchEvent struct {
+	Inputs map[string]WorkflowDispatchInput `yaml:"inputs,omitempty"`
+
+	Extra Extra `yaml:",inline"`
+}
+
+// WorkflowDispatchInput is a model of a [WorkflowDispatchEvent]'s `inputs:`.
+type WorkflowDispatchInput struct {
+	Description string `yaml:"description,omitempty"`
+	Required    bool   `yaml:"required,omitempty"`
+	Default     string `yaml:"default,omitempty"`
+
+	// Type is the type of the input, one of "string", "boolean", "number",
+	// "choice", or "environment". If omitted the input is a string.
+	Type string `yaml:"type,omitempty"`
+
+	// Options are the values that can be chosen for a "choice" input.
+	Options []string `yaml:"options,omitempty"`
+
+	Extra Extra `yaml:",inline"`
+}
+
+// WorkflowRunEvent is a model of a GitHub Actions `workflow_run:` event.
+type WorkflowRunEvent struct {
+	Types          Types    `yaml:"types,omitempty"`
+	Workflows      []string `yaml:"workflows,omitempty"`
+	Branches       []string `yaml:"branches,omitempty"`
+	BranchesIgnore []string `yaml:"branches-ignore,omitempty"`
+
+	Extra Extra `yaml:",inline"`
+}
+
+// Types is a model of an event's `types:` filter.
+type Types []string
+
+func (t *Types) UnmarshalYAML(n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		*t = []string{n.Value}
+	case yaml.SequenceNode:
+		var list []string
+		if err := n.Decode(&list); err != nil {
+			return err
+		}
+
+		*t = list
+	default:
+		return newError(n, "invalid types %v", n.Kind)
+	}
+
+	return nil
+}
+
+// Events returns the names of the events that are configured.
+func (o *On) Events() []string {
+	events := make([]string, 0)
+	if o.BranchProtectionRule != nil {
+		events = append(events, "branch_protection_rule")
+	}
+	if o.CheckRun != nil {
+		events = append(events, "check_run")
+	}
+	if o.CheckSuite != nil {
+		events = append(events, "check_suite")
+	}
+	if o.Create != nil {
+		events = append(events, "create")
+	}
+	if o.Delete != nil {
+		events = append(events, "delete")
+	}
+	if o.Deployment != nil {
+		events = append(events, "deployment")
+	}
+	if o.DeploymentStatus != nil {
+		events = append(events, "deployment_status")
+	}
+	if o.Discussion != nil {
+		events = append(events, "discussion")
+	}
+	if o.DiscussionComment != nil {
+		events = append(events, "discussion_comment")
+	}
+	if o.Fork != nil {
+		events = append(events, "fork")
+	}
+	if o.Gollum != nil {
+		events = append(events, "gollum")
+	}
+	if o.IssueComment != nil {
+		events = append(events, "issue_comment")
+	}
+	if o.Issues != nil {
+		events = append(events, "issues")
+	}
+	if o.Label != nil {
+		events = append(events, "label")
+	}
+	if o.MergeGroup != nil {
+		events = append(events, "merge_group")
+	}
+	if o.Milestone != nil {
+		events = append(events, "milestone")
+	}
+	if o.PageBuild != nil {
+		events = append(events, "page_build")
+	}
+	if o.Public != nil {
+		events = append(events, "public")
+	}
+	if o.PullRequest != nil {
+		events = append(events, "pull_request")
+	}
+	if o.PullRequestReview != nil {
+		events = append(events, "pull_request_review")
+	}
+	if o.PullRequestReviewComment != nil {
+		events = append(events, "pull_request_review_comment")
+	}
+	if o.PullRequestTarget != nil {
+		events = append(events, "pull_request_target")
+	}
+	if o.Push != nil {
+		events = append(events, "push")
+	}
+	if o.RegistryPackage != nil {
+		events = append(events, "registry_package")
+	}
+	if o.Release != nil {
+		events = append(events, "release")
+	}
+	if o.RepositoryDispatch != nil {
+		events = append(events, "repository_dispatch")
+	}
+	if o.Schedule != nil {
+		events = append(events, "schedule")
+	}
+	if o.Status != nil {
+		events = append(events, "status")
+	}
+	if o.Watch != nil {
+		events = append(events, "watch")
+	}
+	if o.WorkflowCall != nil {
+		events = append(events, "workflow_call")
+	}
+	if o.WorkflowDispatch != nil {
+		events = append(events, "workflow_dispatch")
+	}
+	if o.WorkflowRun != nil {
+		events = append(events, "workflow_run")
+	}
+
+	return events
+}
+
+func (o *On) UnmarshalYAML(n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		return o.set(n.Value, nil)
+	case yaml.SequenceNode:
+		var errs []error
+		for _, v := range n.Content {
+			if v.Kind != yaml.ScalarNode {
+				errs = append(errs, newError(v, "invalid on entry %v", v.Kind))
+				continue
+			}
+
+			if err := o.set(v.Value, nil); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		return joinErrors(errs)
+	case yaml.MappingNode:
+		var errs []error
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			if v.ShortTag() == "!!null" {
+				v = nil
+			}
+
+			if err := o.set(k.Value, v); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		return joinErrors(errs)
+	default:
+		return newError(n, "invalid on %v", n.Kind)
+	}
+}
+
+func (o On) MarshalYAML() (any, error) {
+	type on On
+
+	var n yaml.Node
+	if err := n.Encode(on(o)); err != nil {
+		return nil, err
+	}
+
+	// Events without configuration are written as `event:` rather than `event: {}`.
+	for _, v := range n.Content {
+		if v.Kind == yaml.MappingNode && len(v.Content) == 0 {
+			*v = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+		}
+	}
+
+	if o.Schedule != nil && len(o.Schedule) == 0 {
+		n.Content = append(n.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "schedule"},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"},
+		)
+	}
+
+	return &n, nil
+}
+
+// set configures the event with the given name based on the given node. If the
+// node is nil the event is configured without any configuration. Unknown
+// events are kept in [On.Extra].
+func (o *On) set(name string, n *yaml.Node) error {
+	decode := func(v any) error {
+		if n == nil {
+			return nil
+		}
+
+		return n.Decode(v)
+	}
+
+	switch name {
+	case "branch_protection_rule":
+		o.BranchProtectionRule = &Event{}
+		return decode(o.BranchProtectionRule)
+	case "check_run":
+		o.CheckRun = &Event{}
+		return decode(o.CheckRun)
+	case "check_suite":
+		o.CheckSuite = &Event{}
+		return decode(o.CheckSuite)
+	case "create":
+		o.Create = &Event{}
+		return decode(o.Create)
+	case "delete":
+		o.Delete = &Event{}
+		return decode(o.Delete)
+	case "deployment":
+		o.Deployment = &Event{}
+		return decode(o.Deployment)
+	case "deployment_status":
+		o.DeploymentStatus = &Event{}
+		return decode(o.DeploymentStatus)
+	case "discussion":
+		o.Discussion = &Event{}
+		return decode(o.Discussion)
+	case "discussion_comment":
+		o.DiscussionComment = &Event{}
+		return decode(o.DiscussionComment)
+	case "fork":
+		o.Fork = &Event{}
+		return decode(o.Fork)
+	case "gollum":
+		o.Gollum = &Event{}
+		return decode(o.Gollum)
+	case "issue_comment":
+		o.IssueComment = &Event{}
+		return decode(o.IssueComment)
+	case "issues":
+		o.Issues = &Event{}
+		return decode(o.Issues)
+	case "label":
+		o.Label = &Event{}
+		return decode(o.Label)
+	case "merge_group":
+		o.MergeGroup = &Event{}
+		return decode(o.MergeGroup)
+	case "milestone":
+		o.Milestone = &Event{}
+		return decode(o.Milestone)
+	case "page_build":
+		o.PageBuild = &Event{}
+		return decode(o.PageBuild)
+	case "public":
+		o.Public = &Event{}
+		return decode(o.Public)
+	case "pull_request":
+		o.PullRequest = &PullRequestEvent{}
+		return decode(o.PullRequest)
+	case "pull_request_review":
+		o.PullRequestReview = &Event{}
+		return decode(o.PullRequestReview)
+	case "pull_request_review_comment":
+		o.PullRequestReviewComment = &Event{}
+		return decode(o.PullRequestReviewComment)
+	case "pull_request_target":
+		o.PullRequestTarget = &PullRequestEvent{}
+		return decode(o.PullRequestTarget)
+	case "push":
+		o.Push = &PushEvent{}
+		return decode(o.Push)
+	case "registry_package":
+		o.RegistryPackage = &Event{}
+		return decode(o.RegistryPackage)
+	case "release":
+		o.Release = &Event{}
+		return decode(o.Release)
+	case "repository_dispatch":
+		o.RepositoryDispatch = &Event{}
+		return decode(o.RepositoryDispatch)
+	case "schedule":
+		o.Schedule = []Schedule{}
+		return decode(&o.Schedule)
+	case "status":
+		o.Status = &Event{}
+		return decode(o.Status)
+	case "watch":
+		o.Watch = &Event{}
+		return decode(o.Watch)
+	case "workflow_call":
+		o.WorkflowCall = &WorkflowCallEvent{}
+		return decode(o.WorkflowCall)
+	case "workflow_dispatch":
+		o.WorkflowDispatch = &WorkflowDispatchEvent{}
+		return decode(o.WorkflowDispatch)
+	case "workflow_run":
+		o.WorkflowRun = &WorkflowRunEvent{}
+		return decode(o.WorkflowRun)
+	}
+
+	if o.Extra == nil {
+		o.Extra = make(Extra)
+	}
+
+	if n == nil {
+		n = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+	}
+
+	o.Extra[name] = *n
+	return nil
+}
@@ -0,0 +1,475 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package gha
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"go.yaml.in/yaml/v3"
+)
+
+func TestOn(t *testing.T) {
+	type TestCase struct {
+		yaml  string
+		model On
+	}
+
+	okCases := map[string]TestCase{
+		"Single event": {
+			yaml: `push`,
+			model: On{
+				Push: &PushEvent{},
+			},
+		},
+		"List of events": {
+			yaml: `[push, fork, schedule]`,
+			model: On{
+				Fork:     &Event{},
+				Push:     &PushEvent{},
+				Schedule: []Schedule{},
+			},
+		},
+		"Events without configuration": {
+			yaml: `
+pull_request: ~
+workflow_dispatch:
+`,
+			model: On{
+				PullRequest:      &PullRequestEvent{},
+				WorkflowDispatch: &WorkflowDispatchEvent{},
+			},
+		},
+		"Event with activity types": {
+			yaml: `
+issues:
+    types:
+        - opened
+        - labeled
+release:
+    types: published
+`,
+			model: On{
+				Issues: &Event{
+					Types: []string{"opened", "labeled"},
+				},
+				Release: &Event{
+					Types: []string{"published"},
+				},
+			},
+		},
+		"Push filters": {
+			yaml: `
+push:
+    branches:
+        - main
+        - 'releases/**'
+    branches-ignore:
+        - 'releases/**-alpha'
+    tags:
+        - v1.*
+    tags-ignore:
+        - v1.0.*
+    paths:
+        - '**.js'
+    paths-ignore:
+        - 'docs/**'
+`,
+			model: On{
+				Push: &PushEvent{
+					Branches:       []string{"main", "releases/**"},
+					BranchesIgnore: []string{"releases/**-alpha"},
+					Tags:           []string{"v1.*"},
+					TagsIgnore:     []string{"v1.0.*"},
+					Paths:          []string{"**.js"},
+					PathsIgnore:    []string{"docs/**"},
+				},
+			},
+		},
+		"Pull request filters": {
+			yaml: `
+pull_request:
+    types: [opened, synchronize]
+    branches: [main]
+    paths-ignore: ['docs/**']
+pull_request_target:
+    branches-ignore: [wip]
+    paths: ['src/**']
+`,
+			model: On{
+				PullRequest: &PullRequestEvent{
+					Types:       []string{"opened", "synchronize"},
+					Branches:    []string{"main"},
+					PathsIgnore: []string{"docs/**"},
+				},
+				PullRequestTarget: &PullRequestEvent{
+					BranchesIgnore: []string{"wip"},
+					Paths:          []string{"src/**"},
+				},
+			},
+		},
+		"Schedule": {
+			yaml: `
+schedule:
+    - cron: '30 5 * * 1,3'
+    - cron: '30 5 * * 2,4'
+`,
+			model: On{
+				Schedule: []Schedule{
+					{Cron: "30 5 * * 1,3"},
+					{Cron: "30 5 * * 2,4"},
+				},
+			},
+		},
+		"Workflow dispatch inputs": {
+			yaml: `
+workflow_dispatch:
+    inputs:
+        log-level:
+            description: Log level
+            required: true
+            default: warning
+            type: choice
+            options:
+                - info
+                - warning
+                - debug
+        print-tags:
+            description: True to print to STDOUT
+            required: true
+            type: boolean
+            default: false
+        retries:
+            type: number
+            default: 3
+        environment:
+            description: Environment to run tests against
+            type: environment
+        message:
+            description: A message
+`,
+			model: On{
+				WorkflowDispatch: &WorkflowDispatchEvent{
+					Inputs: map[string]WorkflowDispatchInput{
+						"log-level": {
+							Description: "Log level",
+							Required:    true,
+							Default:     "warning",
+							Type:        "choice",
+							Options:     []string{"info", "warning", "debug"},
+						},
+						"print-tags": {
+							Description: "True to print to STDOUT",
+							Required:    true,
+							Default:     "false",
+							Type:        "boolean",
+						},
+						"retries": {
+							Default: "3",
+							Type:    "number",
+						},
+						"environment": {
+							Description: "Environment to run tests against",
+							Type:        "environment",
+						},
+						"message": {
+							Description: "A message",
+						},
+					},
+				},
+			},
+		},
+		"Workflow call interface": {
+			yaml: `
+workflow_call:
+    inputs:
+        config-path:
+            description: Path to the configuration
+            required: true
+            type: string
+        dry-run:
+            type: boolean
+            default: true
+    outputs:
+        firstword:
+            description: The first output string
+            value: ${{ jobs.example.outputs.output1 }}
+    secrets:
+        access-token:
+            description: A token passed from the caller workflow
+            required: true
+        optional-token: {}
+`,
+			model: On{
+				WorkflowCall: &WorkflowCallEvent{
+					Inputs: map[string]WorkflowCallInput{
+						"config-path": {
+							Description: "Path to the configuration",
+							Required:    true,
+							Type:        "string",
+						},
+						"dry-run": {
+							Default: "true",
+							Type:    "boolean",
+						},
+					},
+					Outputs: map[string]Output{
+						"firstword": {
+							Description: "The first output string",
+							Value:       "${{ jobs.example.outputs.output1 }}",
+						},
+					},
+					Secrets: map[string]WorkflowCallSecret{
+						"access-token": {
+							Description: "A token passed from the caller workflow",
+							Required:    true,
+						},
+						"optional-token": {},
+					},
+				},
+			},
+		},
+		"Workflow run": {
+			yaml: `
+workflow_run:
+    workflows: [Build]
+    types: [completed]
+    branches: [main]
+`,
+			model: On{
+				WorkflowRun: &WorkflowRunEvent{
+					Types:     []string{"completed"},
+					Workflows: []string{"Build"},
+					Branches:  []string{"main"},
+				},
+			},
+		},
+		"Unknown event": {
+			yaml: `[push, foobar]`,
+			model: On{
+				Push: &PushEvent{},
+				Extra: Extra{
+					"foobar": {Kind: yaml.ScalarNode, Tag: "!!null"},
+				},
+			},
+		},
+	}
+
+	for name, tt := range okCases {
+		t.Run(name, func(t *testing.T) {
+			var got On
+			if err := yaml.Unmarshal([]byte(tt.yaml), &got); err != nil {
+				t.Fatalf("Want no error, got %#v", err)
+			}
+
+			want := tt.model
+			checkOn(t, &got, &want)
+		})
+	}
+
+	errCases := map[string]TestCase{
+		"invalid list entry": {
+			yaml: `[push, [fork]]`,
+		},
+		"invalid event value": {
+			yaml: `
+push: 42
+`,
+		},
+		"invalid 'types' value": {
+			yaml: `
+issues:
+    types:
+        foo: bar
+`,
+		},
+		"invalid 'branches' value": {
+			yaml: `
+push:
+    branches:
+        foo: bar
+`,
+		},
+		"invalid 'workflow_dispatch.inputs' value": {
+			yaml: `
+workflow_dispatch:
+    inputs: [foo, bar]
+`,
+		},
+		"invalid 'workflow_dispatch.inputs.[*].required' value": {
+			yaml: `
+workflow_dispatch:
+    inputs:
+        foo:
+            required: foobar
+`,
+		},
+		"invalid 'workflow_dispatch.inputs.[*].options' value": {
+			yaml: `
+workflow_dispatch:
+    inputs:
+        foo:
+            type: choice
+            options: foobar
+`,
+		},
+		"invalid 'workflow_call.inputs' value": {
+			yaml: `
+workflow_call:
+    inputs: foobar
+`,
+		},
+		"invalid 'workflow_call.outputs.[*].value' value": {
+			yaml: `
+workflow_call:
+    outputs:
+        foo:
+            value: [3, 14]
+`,
+		},
+		"invalid 'workflow_call.secrets.[*].required' value": {
+			yaml: `
+workflow_call:
+    secrets:
+        foo:
+            required: foobar
+`,
+		},
+		"invalid 'schedule' value": {
+			yaml: `
+schedule:
+    cron: '30 5 * * 1,3'
+`,
+		},
+	}
+
+	for name, tt := range errCases {
+		t.Run(name, func(t *testing.T) {
+			if err := yaml.Unmarshal([]byte(tt.yaml), &tt.model); err == nil {
+				t.Error("Want an error, got none")
+			}
+		})
+	}
+}
+
+func TestOnMarshal(t *testing.T) {
+	type TestCase struct {
+		model On
+		yaml  string
+	}
+
+	testCases := map[string]TestCase{
+		"No events": {
+			model: On{},
+			yaml:  "{}\n",
+		},
+		"Events without configuration": {
+			model: On{
+				Push:     &PushEvent{},
+				Schedule: []Schedule{},
+			},
+			yaml: `push:
+schedule:
+`,
+		},
+		"Events with configuration": {
+			model: On{
+				PullRequest: &PullRequestEvent{
+					Types: []string{"opened"},
+				},
+				Schedule: []Schedule{
+					{Cron: "30 5 * * 1,3"},
+				},
+				WorkflowCall: &WorkflowCallEvent{
+					Inputs: map[string]WorkflowCallInput{
+						"dry-run": {
+							Type:    "boolean",
+							Default: "true",
+						},
+					},
+				},
+			},
+			yaml: `pull_request:
+    types:
+        - opened
+schedule:
+    - cron: 30 5 * * 1,3
+workflow_call:
+    inputs:
+        dry-run:
+            default: "true"
+            type: boolean
+`,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := yaml.Marshal(tt.model)
+			if err != nil {
+				t.Fatalf("Want no error, got %#v", err)
+			}
+
+			if got, want := string(got), tt.yaml; got != want {
+				t.Errorf("Unexpected YAML\ngot:\n%s\nwant:\n%s", got, want)
+			}
+
+			var on On
+			if err := yaml.Unmarshal(got, &on); err != nil {
+				t.Fatalf("Want no error on unmarshal, got %#v", err)
+			}
+
+			checkOn(t, &on, &tt.model)
+		})
+	}
+}
+
+func TestOnEvents(t *testing.T) {
+	type TestCase struct {
+		on   On
+		want []string
+	}
+
+	testCases := map[string]TestCase{
+		"No events": {
+			on:   On{},
+			want: []string{},
+		},
+		"One event": {
+			on: On{
+				Push: &PushEvent{},
+			},
+			want: []string{"push"},
+		},
+		"Multiple events": {
+			on: On{
+				PullRequest:      &PullRequestEvent{},
+				Schedule:         []Schedule{},
+				WorkflowDispatch: &WorkflowDispatchEvent{},
+			},
+			want: []string{"pull_request", "schedule", "workflow_dispatch"},
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got, want := tt.on.Events(), tt.want; !slices.Equal(got, want) {
+				t.Errorf("unexpected result (got %v, want %v)", got, want)
+			}
+		})
+	}
+}
+
+func checkOn(t *testing.T, got, want *On) {
+	t.Helper()
+
+	if got, want := got.Events(), want.Events(); !slices.Equal(got, want) {
+		t.Errorf("Unexpected events (got %v, want %v)", got, want)
+		return
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected event configuration for %v", want.Events())
+	}
+}
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package gha
+
+import (
+	"go.yaml.in/yaml/v3"
+)
+
+// Position is a location in a YAML document. Lines and columns start at 1.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// Positions maps the keys of an object to their position in the YAML document.
+// Keys of nested objects are joined with a dot, e.g. "with.node-version" for
+// the `node-version` key in a step's `with:` object.
+type Positions map[string]Position
+
+func position(n *yaml.Node) Position {
+	return Position{
+		Line:   n.Line,
+		Column: n.Column,
+	}
+}
+
+// positions returns the positions of the keys in the mapping node n and the
+// keys in its mapping values.
+func positions(n *yaml.Node) Positions {
+	result := make(Positions)
+	if n.Kind != yaml.MappingNode {
+		return result
+	}
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		result[k.Value] = position(k)
+
+		if v.Kind != yaml.MappingNode {
+			continue
+		}
+
+		for j := 0; j+1 < len(v.Content); j += 2 {
+			kk := v.Content[j]
+			result[k.Value+"."+kk.Value] = position(kk)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package gha
+
+import (
+	"testing"
+)
+
+func TestWorkflowPositions(t *testing.T) {
+	yaml := `name: Example
+on: push
+env:
+  FOO: bar
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+        with:
+          persist-credentials: false
+      - name: Echo
+        run: echo "$FOO"
+        env:
+          FOO: baz
+  call:
+    uses: ./.github/workflows/called.yml
+    with:
+      foo: bar
+`
+
+	workflow, err := ParseWorkflow([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Want no error, got %#v", err)
+	}
+
+	build := workflow.Jobs["build"]
+	call := workflow.Jobs["call"]
+
+	type TestCase struct {
+		got  Position
+		want Position
+	}
+
+	testCases := map[string]TestCase{
+		"workflow": {
+			got:  workflow.Position,
+			want: Position{Line: 1, Column: 1},
+		},
+		"workflow key": {
+			got:  workflow.Positions["on"],
+			want: Position{Line: 2, Column: 1},
+		},
+		"workflow env entry": {
+			got:  workflow.Positions["env.FOO"],
+			want: Position{Line: 4, Column: 3},
+		},
+		"job key": {
+			got:  workflow.Positions["jobs.build"],
+			want: Position{Line: 6, Column: 3},
+		},
+		"job": {
+			got:  build.Position,
+			want: Position{Line: 7, Column: 5},
+		},
+		"job runs-on": {
+			got:  build.Positions["runs-on"],
+			want: Position{Line: 7, Column: 5},
+		},
+		"job uses": {
+			got:  call.Positions["uses"],
+			want: Position{Line: 17, Column: 5},
+		},
+		"job with entry": {
+			got:  call.Positions["with.foo"],
+			want: Position{Line: 19, Column: 7},
+		},
+		"first step": {
+			got:  build.Steps[0].Position,
+			want: Position{Line: 9, Column: 9},
+		},
+		"step uses": {
+			got:  build.Steps[0].Positions["uses"],
+			want: Position{Line: 9, Column: 9},
+		},
+		"step with entry": {
+			got:  build.Steps[0].Positions["with.persist-credentials"],
+			want: Position{Line: 11, Column: 11},
+		},
+		"second step": {
+			got:  build.Steps[1].Position,
+			want: Position{Line: 12, Column: 9},
+		},
+		"step run": {
+			got:  build.Steps[1].Positions["run"],
+			want: Position{Line: 13, Column: 9},
+		},
+		"step env entry": {
+			got:  build.Steps[1].Positions["env.FOO"],
+			want: Position{Line: 15, Column: 11},
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got, want := tt.got, tt.want; got != want {
+				t.Errorf("Unexpected position (got %+v, want %+v)", got, want)
+			}
+		})
+	}
+
+	if _, ok := build.Steps[0].Positions["run"]; ok {
+		t.Error("Got a position for a key that is not present")
+	}
+}
+
+func TestManifestPositions(t *testing.T) {
+	yaml := `name: Example
+description: An example
+inputs:
+  value:
+    description: A value
+runs:
+  using: composite
+  steps:
+    - run: echo '${{ inputs.value }}'
+      shell: bash
+`
+
+	manifest, err := ParseManifest([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Want no error, got %#v", err)
+	}
+
+	type TestCase struct {
+		got  Position
+		want Position
+	}
+
+	testCases := map[string]TestCase{
+		"manifest": {
+			got:  manifest.Position,
+			want: Position{Line: 1, Column: 1},
+		},
+		"input": {
+			got:  manifest.Positions["inputs.value"],
+			want: Position{Line: 4, Column: 3},
+		},
+		"runs using": {
+			got:  manifest.Positions["runs.using"],
+			want: Position{Line: 7, Column: 3},
+		},
+		"step": {
+			got:  manifest.Runs.Steps[0].Position,
+			want: Position{Line: 9, Column: 7},
+		},
+		"step shell": {
+			got:  manifest.Runs.Steps[0].Positions["shell"],
+			want: Position{Line: 10, Column: 7},
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got, want := tt.got, tt.want; got != want {
+				t.Errorf("Unexpected position (got %+v, want %+v)", got, want)
+			}
+		})
+	}
+}
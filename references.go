@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package gha
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/ericcornelissen/go-gha-models/expr"
+)
+
+// CheckReferences reports the expressions in the workflow that reference a step
+// or job that does not exist, or a job output that is not declared. That is:
+//
+//   - `steps.<id>` where no earlier step in the job has the id <id>;
+//   - `needs.<id>` where <id> is not listed in the job's `needs:`;
+//   - `needs.<id>.outputs.<name>` and `jobs.<id>.outputs.<name>` where the job
+//     <id> does not declare the output <name>.
+//
+// The outputs of jobs that call a reusable workflow are not checked. References
+// in locations where the context is not available are left to [CheckContexts].
+// Expressions that cannot be parsed are ignored.
+//
+// [CheckContexts]: Workflow.CheckContexts
+func (w *Workflow) CheckReferences() Errors {
+	return w.check(checkReferences)
+}
+
+// CheckReferences reports the expressions in the manifest that reference a step
+// that does not exist, i.e. `steps.<id>` where no earlier step has the id <id>.
+//
+// References in locations where the context is not available are left to
+// [CheckContexts]. Expressions that cannot be parsed are ignored.
+//
+// [CheckContexts]: Manifest.CheckContexts
+func (m *Manifest) CheckReferences() Errors {
+	return m.check(checkReferences)
+}
+
+// checkReferences reports the steps, jobs and job outputs referenced in n that
+// do not exist according to a and the workflow being checked.
+func checkReferences(c *checker, key string, n expr.Node, a availability) {
+	expr.Walk(n, func(n expr.Node) bool {
+		context, names := reference(n)
+		if len(names) == 0 || names[0] == "" || !containsFold(a.contexts, context) {
+			return true
+		}
+
+		switch id := names[0]; strings.ToLower(context) {
+		case "steps":
+			if !containsFold(a.steps, id) {
+				c.report(key, "unknown step %q", id)
+			}
+		case "needs":
+			if _, ok := c.job(id); !ok {
+				c.report(key, "unknown job %q", id)
+			} else if !containsFold(a.needs, id) {
+				c.report(key, "job %q is not in needs", id)
+			} else {
+				c.checkOutput(key, id, names[1:])
+			}
+		case "jobs":
+			if _, ok := c.job(id); !ok {
+				c.report(key, "unknown job %q", id)
+			} else {
+				c.checkOutput(key, id, names[1:])
+			}
+		}
+
+		return true
+	})
+}
+
+// checkOutput reports if names, which follow a reference to the job id, are
+// `outputs.<name>` and the job does not declare the output <name>.
+func (c *checker) checkOutput(key, id string, names []string) {
+	if len(names) < 2 || !strings.EqualFold(names[0], "outputs") || names[1] == "" {
+		return
+	}
+
+	job, _ := c.job(id)
+	if job.Uses.Name != "" {
+		return
+	}
+
+	for output := range job.Outputs {
+		if strings.EqualFold(output, names[1]) {
+			return
+		}
+	}
+
+	c.report(key, "job %q has no output %q", id, names[1])
+}
+
+// job returns the job with the given id in the workflow being checked, if any.
+// Job ids are case insensitive.
+func (c *checker) job(id string) (*Job, bool) {
+	if c.workflow == nil {
+		return nil, false
+	}
+
+	name, ok := c.workflow.jobId(id)
+	if !ok {
+		return nil, false
+	}
+
+	job := c.workflow.Jobs[name]
+	return &job, true
+}
+
+// reference returns the context and the property names of a dereference such
+// as `steps.build.outputs.version` or `needs['setup'].result`. Names that are
+// not known statically, e.g. `matrix[inputs.key]` or `needs.*`, are empty. If
+// n is not a dereference of a context the names are nil.
+func reference(n expr.Node) (string, []string) {
+	var (
+		names []string
+		x     = n
+	)
+
+	for {
+		switch node := x.(type) {
+		case *expr.Ident:
+			slices.Reverse(names)
+			return node.Name, names
+		case *expr.Property:
+			names = append(names, node.Name)
+			x = node.X
+		case *expr.Index:
+			name := ""
+			if s, ok := node.Index.(*expr.String); ok {
+				name = s.Value
+			}
+
+			names = append(names, name)
+			x = node.X
+		case *expr.Filter:
+			names = append(names, "")
+			x = node.X
+		default:
+			return "", nil
+		}
+	}
+}
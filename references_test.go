@@ -0,0 +1,324 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package gha
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ericcornelissen/go-gha-models/expr"
+)
+
+func TestWorkflowCheckReferences(t *testing.T) {
+	type TestCase struct {
+		yaml string
+		want Errors
+	}
+
+	testCases := map[string]TestCase{
+		"Valid references": {
+			yaml: `on:
+  workflow_call:
+    outputs:
+      version:
+        value: ${{ jobs.build.outputs.version }}
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    outputs:
+      version: ${{ steps.version.outputs.version }}
+    environment:
+      name: production
+      url: ${{ steps.Version.outputs.url }}
+    steps:
+      - id: version
+        run: echo "version=1.0.0" >> "$GITHUB_OUTPUT"
+      - if: steps.version.outcome == 'success'
+        run: echo ${{ steps['version'].outputs.version }}
+  call:
+    uses: ./.github/workflows/called.yml
+  test:
+    needs: [build, call]
+    if: needs.build.result == 'success'
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo ${{ needs.build.outputs.VERSION }} ${{ needs.call.outputs.anything }}
+      - run: echo ${{ join(needs.*.result) }} ${{ needs[matrix.job].result }}
+`,
+			want: nil,
+		},
+		"Case insensitive job ids": {
+			yaml: `on: push
+jobs:
+  Build:
+    runs-on: ubuntu-latest
+    outputs:
+      version: ${{ steps.version.outputs.version }}
+    steps:
+      - id: version
+        run: echo "version=1.0.0" >> "$GITHUB_OUTPUT"
+  test:
+    needs: build
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo ${{ needs.BUILD.outputs.version }}
+`,
+			want: nil,
+		},
+		"Unknown step": {
+			yaml: `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    outputs:
+      version: ${{ steps.versions.outputs.version }}
+    steps:
+      - id: version
+        run: echo "version=1.0.0" >> "$GITHUB_OUTPUT"
+      - id: echo
+        run: echo ${{ steps.echo.outputs.version }}
+      - run: echo ${{ steps.foo.outputs.bar }} ${{ steps.foo.outcome }}
+`,
+			want: Errors{
+				{
+					Path:    "jobs.build.outputs.version",
+					Line:    6,
+					Column:  7,
+					Message: `unknown step "versions"`,
+				},
+				{
+					Path:    "jobs.build.steps[1].run",
+					Line:    11,
+					Column:  9,
+					Message: `unknown step "echo"`,
+				},
+				{
+					Path:    "jobs.build.steps[2].run",
+					Line:    12,
+					Column:  9,
+					Message: `unknown step "foo"`,
+				},
+			},
+		},
+		"Steps of other jobs": {
+			yaml: `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - id: version
+        run: echo "version=1.0.0" >> "$GITHUB_OUTPUT"
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - id: test
+        run: make test
+      - run: echo ${{ steps.version.outputs.version }}
+`,
+			want: Errors{
+				{
+					Path:    "jobs.test.steps[1].run",
+					Line:    13,
+					Column:  9,
+					Message: `unknown step "version"`,
+				},
+			},
+		},
+		"Needs": {
+			yaml: `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    outputs:
+      version: ${{ steps.version.outputs.version }}
+    steps:
+      - id: version
+        run: echo "version=1.0.0" >> "$GITHUB_OUTPUT"
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make lint
+  test:
+    needs: build
+    if: needs.lint.result == 'success' || needs.setup.result == 'success'
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo ${{ needs.build.outputs.versions }}
+`,
+			want: Errors{
+				{
+					Path:    "jobs.test.if",
+					Line:    16,
+					Column:  5,
+					Message: `job "lint" is not in needs`,
+				},
+				{
+					Path:    "jobs.test.if",
+					Line:    16,
+					Column:  5,
+					Message: `unknown job "setup"`,
+				},
+				{
+					Path:    "jobs.test.steps[0].run",
+					Line:    19,
+					Column:  9,
+					Message: `job "build" has no output "versions"`,
+				},
+			},
+		},
+		"Workflow call outputs": {
+			yaml: `on:
+  workflow_call:
+    outputs:
+      a:
+        value: ${{ jobs.build.outputs.b }}
+      c:
+        value: ${{ jobs.deploy.outputs.d }}
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make
+`,
+			want: Errors{
+				{
+					Path:    "on.workflow_call.outputs.a.value",
+					Line:    2,
+					Column:  3,
+					Message: `job "build" has no output "b"`,
+				},
+				{
+					Path:    "on.workflow_call.outputs.c.value",
+					Line:    2,
+					Column:  3,
+					Message: `unknown job "deploy"`,
+				},
+			},
+		},
+		"Unavailable context": {
+			yaml: `on: push
+jobs:
+  build:
+    if: steps.foo.outcome == 'success'
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo ${{ steps.foo.outcome }}
+`,
+			want: nil,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			workflow, err := ParseWorkflow([]byte(tt.yaml))
+			if err != nil {
+				t.Fatalf("Want no error, got %#v", err)
+			}
+
+			checkErrors(t, workflow.CheckReferences(), tt.want)
+		})
+	}
+}
+
+func TestManifestCheckReferences(t *testing.T) {
+	yaml := `name: Example
+description: An example action
+outputs:
+  version:
+    value: ${{ steps.version.outputs.version }}
+  other:
+    value: ${{ steps.other.outputs.version }}
+runs:
+  using: composite
+  steps:
+    - id: version
+      run: echo "version=1.0.0" >> "$GITHUB_OUTPUT"
+      shell: bash
+    - run: echo ${{ steps.version.outputs.version }} ${{ steps.check.outcome }}
+      shell: bash
+    - id: check
+      run: make check
+      shell: bash
+`
+
+	manifest, err := ParseManifest([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Want no error, got %#v", err)
+	}
+
+	want := Errors{
+		{
+			Path:    "outputs.other.value",
+			Line:    6,
+			Column:  3,
+			Message: `unknown step "other"`,
+		},
+		{
+			Path:    "runs.steps[1].run",
+			Line:    14,
+			Column:  7,
+			Message: `unknown step "check"`,
+		},
+	}
+
+	checkErrors(t, manifest.CheckReferences(), want)
+}
+
+func TestReference(t *testing.T) {
+	type TestCase struct {
+		src     string
+		context string
+		names   []string
+	}
+
+	testCases := map[string]TestCase{
+		"Context": {
+			src:     `steps`,
+			context: "steps",
+			names:   nil,
+		},
+		"Properties": {
+			src:     `steps.build.outputs.version`,
+			context: "steps",
+			names:   []string{"build", "outputs", "version"},
+		},
+		"String index": {
+			src:     `needs['setup'].result`,
+			context: "needs",
+			names:   []string{"setup", "result"},
+		},
+		"Dynamic index": {
+			src:     `needs[matrix.job].result`,
+			context: "needs",
+			names:   []string{"", "result"},
+		},
+		"Filter": {
+			src:     `needs.*.result`,
+			context: "needs",
+			names:   []string{"", "result"},
+		},
+		"Function call": {
+			src:     `fromJSON(inputs.config).foo`,
+			context: "",
+			names:   nil,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			n, err := expr.Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Want no error, got %v", err)
+			}
+
+			context, names := reference(n)
+			if got, want := context, tt.context; got != want {
+				t.Errorf("Unexpected context (got %q, want %q)", got, want)
+			}
+
+			if got, want := names, tt.names; !reflect.DeepEqual(got, want) {
+				t.Errorf("Unexpected names (got %q, want %q)", got, want)
+			}
+		})
+	}
+}
@@ -6,6 +6,10 @@ import (
 	"testing"
 )
 
+func ptr[T any](v T) *T {
+	return &v
+}
+
 func checkMap(t *testing.T, got, want map[string]string) {
 	t.Helper()
 
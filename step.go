@@ -3,7 +3,8 @@
 package gha
 
 import (
-	"fmt"
+	"path"
+	"strconv"
 	"strings"
 
 	"go.yaml.in/yaml/v3"
@@ -20,28 +21,352 @@ type Step struct {
 	WorkingDirectory string            `yaml:"working-directory,omitempty"`
 	Shell            string            `yaml:"shell,omitempty"`
 	Run              string            `yaml:"run,omitempty"`
-	With             map[string]string `yaml:"with,omitempty"`
+	With             map[string]Value  `yaml:"with,omitempty"`
 	Env              map[string]string `yaml:"env,omitempty"`
+
+	Extra Extra `yaml:",inline"`
+
+	// Position is the position of the step in the YAML document.
+	Position Position `yaml:"-"`
+
+	// Positions are the positions of the keys of the step, e.g. "uses" or
+	// "with.node-version".
+	Positions Positions `yaml:"-"`
+}
+
+func (s *Step) UnmarshalYAML(n *yaml.Node) error {
+	type step Step
+	if err := n.Decode((*step)(s)); err != nil {
+		return err
+	}
+
+	s.Position = position(n)
+	s.Positions = positions(n)
+	nullValues(n, s.With)
+
+	return nil
+}
+
+// Value is a model of a `with:` value.
+type Value struct {
+	// Kind is the kind of scalar the value is written as.
+	Kind ValueKind
+
+	// Raw is the value as written, excluding quotes.
+	Raw string
+}
+
+// ValueKind is the kind of scalar of a [Value].
+type ValueKind int
+
+const (
+	// ValueString is a string, quoted or not, e.g. `foo` or `"true"`.
+	ValueString ValueKind = iota
+
+	// ValueBool is a boolean, e.g. `true`.
+	ValueBool
+
+	// ValueInt is an integer, e.g. `42` or `0x2A`.
+	ValueInt
+
+	// ValueFloat is a floating point number, e.g. `3.14`.
+	ValueFloat
+
+	// ValueNull is null, e.g. `~`, `null` or no value at all.
+	ValueNull
+)
+
+func (v Value) String() string {
+	return v.Raw
+}
+
+func (v *Value) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind != yaml.ScalarNode {
+		return newError(n, "cannot unmarshal %s into a gha.Value struct", n.Tag)
+	}
+
+	switch n.ShortTag() {
+	case "!!bool":
+		v.Kind = ValueBool
+	case "!!int":
+		v.Kind = ValueInt
+	case "!!float":
+		v.Kind = ValueFloat
+	case "!!null":
+		v.Kind = ValueNull
+	default:
+		v.Kind = ValueString
+	}
+
+	v.Raw = n.Value
+
+	return nil
+}
+
+// nullValues sets the `with:` values in the mapping node n that are null to a
+// [ValueNull] in with. This is needed because the YAML decoder does not call
+// [Value.UnmarshalYAML] for null values.
+func nullValues(n *yaml.Node, with map[string]Value) {
+	if with == nil {
+		return
+	}
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		if k.Value != "with" || v.Kind != yaml.MappingNode {
+			continue
+		}
+
+		for j := 0; j+1 < len(v.Content); j += 2 {
+			if kk, vv := v.Content[j], v.Content[j+1]; vv.ShortTag() == "!!null" {
+				with[kk.Value] = Value{Kind: ValueNull, Raw: vv.Value}
+			}
+		}
+	}
 }
 
-// Uses is a model of a step `uses:` value.
+func (v Value) MarshalYAML() (any, error) {
+	value := &yaml.Node{Kind: yaml.ScalarNode, Value: v.Raw}
+	switch v.Kind {
+	case ValueBool:
+		value.Tag = "!!bool"
+	case ValueInt:
+		value.Tag = "!!int"
+	case ValueFloat:
+		value.Tag = "!!float"
+	case ValueNull:
+		value.Tag = "!!null"
+	default:
+		value.Tag = "!!str"
+	}
+
+	return value, nil
+}
+
+// Uses is a model of a step or job `uses:` value.
 type Uses struct {
-	// Name is the name of the Action that is used. Typically <owner>/<repository>.
+	// Name is the name of the Action or reusable workflow that is used. Typically
+	// <owner>/<repository> or <owner>/<repository>/<path>.
 	Name string
 
-	// Ref is the git reference used for the Action. Typically a tag ref, branch ref, or commit SHA.
+	// Ref is the git reference used for the Action or reusable workflow. Typically
+	// a tag ref, branch ref, or commit SHA.
 	Ref string
 
 	// Annotation is the comment after the `uses:` value, if any.
 	Annotation string
 }
 
+// UsesKind is the kind of reference of a [Uses].
+type UsesKind int
+
+const (
+	// UsesRepository is an Action at the root of a repository.
+	UsesRepository UsesKind = iota
+
+	// UsesRepositoryPath is an Action or reusable workflow in a subdirectory of
+	// a repository.
+	UsesRepositoryPath
+
+	// UsesLocal is an Action or reusable workflow in the same repository.
+	UsesLocal
+
+	// UsesDocker is a Docker container image.
+	UsesDocker
+)
+
+// DockerImage is a model of the image of a Docker `uses:` value.
+type DockerImage struct {
+	// Registry is the host of the registry, empty for Docker Hub.
+	Registry string
+
+	// Image is the name of the image, e.g. "alpine" or "cloud-builders/gradle".
+	Image string
+
+	// Tag is the tag of the image, if any.
+	Tag string
+
+	// Digest is the digest of the image, if any.
+	Digest string
+}
+
+// RefKind is the kind of git reference of a [Uses].
+type RefKind int
+
+const (
+	// RefOther is any reference that is not a commit SHA or version, such as a
+	// branch name.
+	RefOther RefKind = iota
+
+	// RefSHA is a full length commit SHA.
+	RefSHA
+
+	// RefShortSHA is an abbreviated commit SHA.
+	RefShortSHA
+
+	// RefVersion is a semantic version-like tag, such as "v4" or "v4.1.0".
+	RefVersion
+)
+
+// Version is a model of a semantic version-like git reference.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+
+	// Prerelease is the pre-release identifier, e.g. "beta.1" for "v1.0.0-beta.1".
+	Prerelease string
+
+	// Precision is the number of version numbers specified, e.g. 1 for "v4" and
+	// 3 for "v4.1.0".
+	Precision int
+}
+
+const dockerPrefix = "docker://"
+
+// Kind returns the kind of reference of the uses value.
+func (u *Uses) Kind() UsesKind {
+	switch {
+	case u.IsLocal():
+		return UsesLocal
+	case u.IsDocker():
+		return UsesDocker
+	case u.Path() != "":
+		return UsesRepositoryPath
+	default:
+		return UsesRepository
+	}
+}
+
+// RefKind returns the kind of git reference of the uses value. A reference that
+// is both a valid version and a valid abbreviated commit SHA, e.g. "1234567",
+// is considered a version.
+func (u *Uses) RefKind() RefKind {
+	ref := u.Ref
+	switch {
+	case len(ref) == 40 && isHex(ref):
+		return RefSHA
+	case isVersion(ref):
+		return RefVersion
+	case len(ref) >= 7 && len(ref) < 40 && isHex(ref):
+		return RefShortSHA
+	default:
+		return RefOther
+	}
+}
+
+// RefVersion returns the version of the uses value's git reference. The boolean
+// is false if the reference is not a version.
+func (u *Uses) RefVersion() (Version, bool) {
+	if u.RefKind() != RefVersion {
+		return Version{}, false
+	}
+
+	return parseVersion(u.Ref)
+}
+
+// AnnotationVersion returns the version in the annotation of the uses value,
+// e.g. for a version comment such as `# v4.1.0`. The boolean is false if the
+// annotation is not a version comment.
+func (u *Uses) AnnotationVersion() (Version, bool) {
+	fields := strings.Fields(u.Annotation)
+	if len(fields) == 0 {
+		return Version{}, false
+	}
+
+	return parseVersion(strings.TrimPrefix(fields[0], "tag="))
+}
+
 // IsLocal reports whether the uses value is for a local or remote Action.
 func (u *Uses) IsLocal() bool {
 	name := u.Name
 	return len(name) > 0 && name[0] == '.'
 }
 
+// IsDocker reports whether the uses value is for a Docker container image.
+func (u *Uses) IsDocker() bool {
+	return strings.HasPrefix(u.Name, dockerPrefix)
+}
+
+// DockerImage returns the image of a Docker uses value. The boolean is false if
+// the uses value is not for a Docker container image.
+func (u *Uses) DockerImage() (DockerImage, bool) {
+	var image DockerImage
+	if !u.IsDocker() {
+		return image, false
+	}
+
+	ref := strings.TrimPrefix(u.Name, dockerPrefix)
+	ref, image.Digest, _ = strings.Cut(ref, "@")
+
+	if host, rest, ok := strings.Cut(ref, "/"); ok {
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			image.Registry = host
+			ref = rest
+		}
+	}
+
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		image.Tag = ref[i+1:]
+		ref = ref[:i]
+	}
+
+	image.Image = ref
+
+	return image, true
+}
+
+// Owner returns the owner of the repository of a remote Action or reusable
+// workflow. It returns the empty string for local and Docker uses values.
+func (u *Uses) Owner() string {
+	if u.IsLocal() || u.IsDocker() {
+		return ""
+	}
+
+	owner, _, _ := strings.Cut(u.Name, "/")
+	return owner
+}
+
+// Repository returns the name of the repository of a remote Action or reusable
+// workflow, excluding the owner. It returns the empty string for local and
+// Docker uses values.
+func (u *Uses) Repository() string {
+	if u.IsLocal() || u.IsDocker() {
+		return ""
+	}
+
+	parts := strings.SplitN(u.Name, "/", 3)
+	if len(parts) < 2 {
+		return ""
+	}
+
+	return parts[1]
+}
+
+// Path returns the path of the Action or reusable workflow within its
+// repository, e.g. ".github/workflows/build.yml". It returns the empty string
+// for an Action at the root of its repository and for Docker uses values.
+func (u *Uses) Path() string {
+	if u.IsLocal() {
+		if p := path.Clean(u.Name); p != "." {
+			return p
+		}
+
+		return ""
+	}
+
+	if u.IsDocker() {
+		return ""
+	}
+
+	parts := strings.SplitN(u.Name, "/", 3)
+	if len(parts) < 3 {
+		return ""
+	}
+
+	return parts[2]
+}
+
 func (u *Uses) String() string {
 	if len(u.Ref) == 0 {
 		return u.Name
@@ -52,7 +377,7 @@ func (u *Uses) String() string {
 
 func (u *Uses) UnmarshalYAML(n *yaml.Node) error {
 	if n.Kind != yaml.ScalarNode {
-		return fmt.Errorf("cannot unmarshal %s into a gha.Uses struct", n.Tag)
+		return newError(n, "cannot unmarshal %s into a gha.Uses struct", n.Tag)
 	}
 
 	if n.Value == "" {
@@ -60,8 +385,12 @@ func (u *Uses) UnmarshalYAML(n *yaml.Node) error {
 	}
 
 	i := strings.LastIndex(n.Value, "@")
+	if strings.HasPrefix(n.Value, dockerPrefix) {
+		i = -1 // the digest of a Docker image is not a git ref
+	}
+
 	if i == 0 || i == len(n.Value)-1 {
-		return fmt.Errorf("invalid `uses` value (%q)", n.Value)
+		return newError(n, "invalid `uses` value (%q)", n.Value)
 	}
 
 	if i > 0 {
@@ -75,3 +404,59 @@ func (u *Uses) UnmarshalYAML(n *yaml.Node) error {
 
 	return nil
 }
+
+func (u Uses) MarshalYAML() (any, error) {
+	uses := &yaml.Node{
+		Kind:  yaml.ScalarNode,
+		Tag:   "!!str",
+		Value: u.String(),
+	}
+
+	if u.Annotation != "" {
+		uses.LineComment = "# " + u.Annotation
+	}
+
+	return uses, nil
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if !('0' <= r && r <= '9') && !('a' <= r && r <= 'f') && !('A' <= r && r <= 'F') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isVersion(s string) bool {
+	_, ok := parseVersion(s)
+	return ok
+}
+
+func parseVersion(s string) (Version, bool) {
+	var version Version
+
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "v"), "V")
+	s, _, _ = strings.Cut(s, "+")
+	s, version.Prerelease, _ = strings.Cut(s, "-")
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return version, false
+	}
+
+	numbers := []*int{&version.Major, &version.Minor, &version.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return Version{}, false
+		}
+
+		*numbers[i] = n
+	}
+
+	version.Precision = len(parts)
+
+	return version, true
+}
@@ -80,8 +80,37 @@ with:
     foo: bar
 `,
 			model: Step{
-				With: map[string]string{
-					"foo": "bar",
+				With: map[string]Value{
+					"foo": {Raw: "bar"},
+				},
+			},
+		},
+		"With a 'with:' of various kinds": {
+			yaml: `
+with:
+    string: bar
+    quoted: "true"
+    bool: false
+    int: 42
+    float: 3.14
+    multiline: |
+        foo
+        bar
+    tilde: ~
+    null: null
+    empty:
+`,
+			model: Step{
+				With: map[string]Value{
+					"string":    {Kind: ValueString, Raw: "bar"},
+					"quoted":    {Kind: ValueString, Raw: "true"},
+					"bool":      {Kind: ValueBool, Raw: "false"},
+					"int":       {Kind: ValueInt, Raw: "42"},
+					"float":     {Kind: ValueFloat, Raw: "3.14"},
+					"multiline": {Kind: ValueString, Raw: "foo\nbar\n"},
+					"tilde":     {Kind: ValueNull, Raw: "~"},
+					"null":      {Kind: ValueNull, Raw: "null"},
+					"empty":     {Kind: ValueNull, Raw: ""},
 				},
 			},
 		},
@@ -193,6 +222,12 @@ run: ['foo', 'bar']
 		"invalid 'with' value": {
 			yaml: `
 with: not a map
+`,
+		},
+		"invalid 'with.[*]' value": {
+			yaml: `
+with:
+    foo: [3, 14]
 `,
 		},
 		"invalid 'env' value": {
@@ -211,6 +246,94 @@ env: not a map
 	}
 }
 
+func TestStepMarshal(t *testing.T) {
+	type TestCase struct {
+		model Step
+		yaml  string
+	}
+
+	testCases := map[string]TestCase{
+		"With a 'uses:'": {
+			model: Step{
+				Uses: Uses{
+					Name: "actions/checkout",
+					Ref:  "v4",
+				},
+			},
+			yaml: "uses: actions/checkout@v4\n",
+		},
+		"With an annotated 'uses:'": {
+			model: Step{
+				Uses: Uses{
+					Name:       "actions/checkout",
+					Ref:        "8f4b7f84864484a7bf31766abe9204da3cbe65b3",
+					Annotation: "v4.2.0",
+				},
+			},
+			yaml: "uses: actions/checkout@8f4b7f84864484a7bf31766abe9204da3cbe65b3 # v4.2.0\n",
+		},
+		"With a local 'uses:'": {
+			model: Step{
+				Uses: Uses{
+					Name: "./.github/actions/hello-world-action",
+				},
+			},
+			yaml: "uses: ./.github/actions/hello-world-action\n",
+		},
+		"With a 'with:' of various kinds": {
+			model: Step{
+				With: map[string]Value{
+					"bool":   {Kind: ValueBool, Raw: "true"},
+					"float":  {Kind: ValueFloat, Raw: "3.14"},
+					"int":    {Kind: ValueInt, Raw: "42"},
+					"null":   {Kind: ValueNull, Raw: "~"},
+					"quoted": {Kind: ValueString, Raw: "false"},
+					"string": {Kind: ValueString, Raw: "bar"},
+				},
+			},
+			yaml: `with:
+    bool: true
+    float: 3.14
+    int: 42
+    "null": ~
+    quoted: "false"
+    string: bar
+`,
+		},
+		"With extra keys": {
+			model: Step{
+				Run: "make",
+				Extra: Extra{
+					"future-key": {Kind: yaml.ScalarNode, Value: "future value"},
+				},
+			},
+			yaml: `run: make
+future-key: future value
+`,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := yaml.Marshal(tt.model)
+			if err != nil {
+				t.Fatalf("Want no error, got %#v", err)
+			}
+
+			if got, want := string(got), tt.yaml; got != want {
+				t.Errorf("Unexpected YAML\ngot:\n%s\nwant:\n%s", got, want)
+			}
+
+			var step Step
+			if err := yaml.Unmarshal(got, &step); err != nil {
+				t.Fatalf("Want no error on unmarshal, got %#v", err)
+			}
+
+			checkStep(t, &step, &tt.model)
+		})
+	}
+}
+
 func TestUses(t *testing.T) {
 	type TestCase struct {
 		yaml  string
@@ -285,6 +408,12 @@ func TestUses(t *testing.T) {
 				Name: "docker://gcr.io/cloud-builders/gradle",
 			},
 		},
+		"Docker action with digest": {
+			yaml: `docker://alpine@sha256:c5b1261d6d3e43071626931fc004f70149baeba2c8ec672bd4f27761f8e1ad6b`,
+			model: Uses{
+				Name: "docker://alpine@sha256:c5b1261d6d3e43071626931fc004f70149baeba2c8ec672bd4f27761f8e1ad6b",
+			},
+		},
 	}
 
 	for name, tt := range okCases {
@@ -375,6 +504,497 @@ func TestUsesIsLocal(t *testing.T) {
 	}
 }
 
+func TestUsesKind(t *testing.T) {
+	type TestCase struct {
+		uses Uses
+		want UsesKind
+	}
+
+	testCases := map[string]TestCase{
+		"Remote action": {
+			uses: Uses{
+				Name: "actions/checkout",
+				Ref:  "v5.0.1",
+			},
+			want: UsesRepository,
+		},
+		"Remote action, in subdirectory": {
+			uses: Uses{
+				Name: "github/codeql-action/analyze",
+				Ref:  "main",
+			},
+			want: UsesRepositoryPath,
+		},
+		"Remote reusable workflow": {
+			uses: Uses{
+				Name: "octo-org/example-repo/.github/workflows/called-workflow.yml",
+				Ref:  "main",
+			},
+			want: UsesRepositoryPath,
+		},
+		"Local action": {
+			uses: Uses{
+				Name: "./.github/actions/ghasum",
+			},
+			want: UsesLocal,
+		},
+		"Local action, root": {
+			uses: Uses{
+				Name: ".",
+			},
+			want: UsesLocal,
+		},
+		"Docker action": {
+			uses: Uses{
+				Name: "docker://alpine:3.8",
+			},
+			want: UsesDocker,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got, want := tt.uses.Kind(), tt.want; got != want {
+				t.Errorf("unexpected result (got %d, want %d)", got, want)
+			}
+		})
+	}
+}
+
+func TestUsesDockerImage(t *testing.T) {
+	type TestCase struct {
+		uses Uses
+		want DockerImage
+	}
+
+	dockerCases := map[string]TestCase{
+		"Docker Hub image": {
+			uses: Uses{
+				Name: "docker://alpine",
+			},
+			want: DockerImage{
+				Image: "alpine",
+			},
+		},
+		"Docker Hub image with tag": {
+			uses: Uses{
+				Name: "docker://alpine:3.8",
+			},
+			want: DockerImage{
+				Image: "alpine",
+				Tag:   "3.8",
+			},
+		},
+		"Docker Hub image with namespace": {
+			uses: Uses{
+				Name: "docker://library/alpine:3.8",
+			},
+			want: DockerImage{
+				Image: "library/alpine",
+				Tag:   "3.8",
+			},
+		},
+		"Docker Hub image with digest": {
+			uses: Uses{
+				Name: "docker://alpine@sha256:c5b1261d6d3e43071626931fc004f70149baeba2c8ec672bd4f27761f8e1ad6b",
+			},
+			want: DockerImage{
+				Image:  "alpine",
+				Digest: "sha256:c5b1261d6d3e43071626931fc004f70149baeba2c8ec672bd4f27761f8e1ad6b",
+			},
+		},
+		"GitHub Packages Container registry image": {
+			uses: Uses{
+				Name: "docker://ghcr.io/foo/bar",
+			},
+			want: DockerImage{
+				Registry: "ghcr.io",
+				Image:    "foo/bar",
+			},
+		},
+		"Public registry image with tag and digest": {
+			uses: Uses{
+				Name: "docker://gcr.io/cloud-builders/gradle:8@sha256:c5b1261d6d3e43071626931fc004f70149baeba2c8ec672bd4f27761f8e1ad6b",
+			},
+			want: DockerImage{
+				Registry: "gcr.io",
+				Image:    "cloud-builders/gradle",
+				Tag:      "8",
+				Digest:   "sha256:c5b1261d6d3e43071626931fc004f70149baeba2c8ec672bd4f27761f8e1ad6b",
+			},
+		},
+		"Registry with port": {
+			uses: Uses{
+				Name: "docker://localhost:5000/foo/bar:latest",
+			},
+			want: DockerImage{
+				Registry: "localhost:5000",
+				Image:    "foo/bar",
+				Tag:      "latest",
+			},
+		},
+	}
+
+	for name, tt := range dockerCases {
+		t.Run(name, func(t *testing.T) {
+			got, ok := tt.uses.DockerImage()
+			if !ok {
+				t.Fatal("Want a Docker image, got none")
+			}
+
+			if want := tt.want; got != want {
+				t.Errorf("unexpected result (got %+v, want %+v)", got, want)
+			}
+		})
+	}
+
+	otherCases := map[string]Uses{
+		"Remote action": {
+			Name: "actions/checkout",
+			Ref:  "v5.0.1",
+		},
+		"Local action": {
+			Name: "./.github/actions/ghasum",
+		},
+	}
+
+	for name, tt := range otherCases {
+		t.Run(name, func(t *testing.T) {
+			if _, ok := tt.DockerImage(); ok {
+				t.Error("Want no Docker image, got one")
+			}
+		})
+	}
+}
+
+func TestUsesRefKind(t *testing.T) {
+	type TestCase struct {
+		ref  string
+		want RefKind
+	}
+
+	testCases := map[string]TestCase{
+		"Full commit SHA": {
+			ref:  "8f4b7f84864484a7bf31766abe9204da3cbe65b3",
+			want: RefSHA,
+		},
+		"Short commit SHA": {
+			ref:  "8f4b7f8",
+			want: RefShortSHA,
+		},
+		"Major version": {
+			ref:  "v4",
+			want: RefVersion,
+		},
+		"Minor version": {
+			ref:  "v4.1",
+			want: RefVersion,
+		},
+		"Patch version": {
+			ref:  "v4.1.0",
+			want: RefVersion,
+		},
+		"Version without prefix": {
+			ref:  "4.1.0",
+			want: RefVersion,
+		},
+		"Pre-release version": {
+			ref:  "v1.0.0-beta.1",
+			want: RefVersion,
+		},
+		"Branch": {
+			ref:  "main",
+			want: RefOther,
+		},
+		"Release branch": {
+			ref:  "releases/v1",
+			want: RefOther,
+		},
+		"Too many version numbers": {
+			ref:  "v1.2.3.4",
+			want: RefOther,
+		},
+		"Too short to be a commit SHA": {
+			ref:  "abc",
+			want: RefOther,
+		},
+		"Too long to be a commit SHA": {
+			ref:  "8f4b7f84864484a7bf31766abe9204da3cbe65b3a",
+			want: RefOther,
+		},
+		"No ref": {
+			ref:  "",
+			want: RefOther,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			uses := Uses{Name: "actions/checkout", Ref: tt.ref}
+			if got, want := uses.RefKind(), tt.want; got != want {
+				t.Errorf("unexpected result (got %d, want %d)", got, want)
+			}
+		})
+	}
+}
+
+func TestUsesRefVersion(t *testing.T) {
+	type TestCase struct {
+		ref  string
+		want Version
+	}
+
+	okCases := map[string]TestCase{
+		"Major version": {
+			ref: "v4",
+			want: Version{
+				Major:     4,
+				Precision: 1,
+			},
+		},
+		"Minor version": {
+			ref: "v4.1",
+			want: Version{
+				Major:     4,
+				Minor:     1,
+				Precision: 2,
+			},
+		},
+		"Patch version": {
+			ref: "v4.1.2",
+			want: Version{
+				Major:     4,
+				Minor:     1,
+				Patch:     2,
+				Precision: 3,
+			},
+		},
+		"Version without prefix": {
+			ref: "1.0.0",
+			want: Version{
+				Major:     1,
+				Precision: 3,
+			},
+		},
+		"Pre-release version with build metadata": {
+			ref: "v1.0.0-rc.1+20250101",
+			want: Version{
+				Major:      1,
+				Prerelease: "rc.1",
+				Precision:  3,
+			},
+		},
+	}
+
+	for name, tt := range okCases {
+		t.Run(name, func(t *testing.T) {
+			uses := Uses{Name: "actions/checkout", Ref: tt.ref}
+
+			got, ok := uses.RefVersion()
+			if !ok {
+				t.Fatal("Want a version, got none")
+			}
+
+			if want := tt.want; got != want {
+				t.Errorf("unexpected result (got %+v, want %+v)", got, want)
+			}
+		})
+	}
+
+	errCases := map[string]string{
+		"Commit SHA":     "8f4b7f84864484a7bf31766abe9204da3cbe65b3",
+		"Branch":         "main",
+		"Empty number":   "v1..0",
+		"Non-numeric":    "v1.x",
+		"Missing number": "v",
+	}
+
+	for name, ref := range errCases {
+		t.Run(name, func(t *testing.T) {
+			uses := Uses{Name: "actions/checkout", Ref: ref}
+			if _, ok := uses.RefVersion(); ok {
+				t.Error("Want no version, got one")
+			}
+		})
+	}
+}
+
+func TestUsesAnnotationVersion(t *testing.T) {
+	type TestCase struct {
+		annotation string
+		want       Version
+	}
+
+	okCases := map[string]TestCase{
+		"Version comment": {
+			annotation: "v4.1.0",
+			want: Version{
+				Major:     4,
+				Minor:     1,
+				Precision: 3,
+			},
+		},
+		"Major version comment": {
+			annotation: "v4",
+			want: Version{
+				Major:     4,
+				Precision: 1,
+			},
+		},
+		"Version comment with trailing text": {
+			annotation: "v4.1.0 (latest)",
+			want: Version{
+				Major:     4,
+				Minor:     1,
+				Precision: 3,
+			},
+		},
+		"Tag comment": {
+			annotation: "tag=v2.0.1",
+			want: Version{
+				Major:     2,
+				Patch:     1,
+				Precision: 3,
+			},
+		},
+	}
+
+	for name, tt := range okCases {
+		t.Run(name, func(t *testing.T) {
+			uses := Uses{Name: "actions/checkout", Annotation: tt.annotation}
+
+			got, ok := uses.AnnotationVersion()
+			if !ok {
+				t.Fatal("Want a version, got none")
+			}
+
+			if want := tt.want; got != want {
+				t.Errorf("unexpected result (got %+v, want %+v)", got, want)
+			}
+		})
+	}
+
+	errCases := map[string]string{
+		"No annotation":   "",
+		"Other comment":   "pinned by renovate",
+		"Branch comment":  "main",
+		"Whitespace only": "   ",
+	}
+
+	for name, annotation := range errCases {
+		t.Run(name, func(t *testing.T) {
+			uses := Uses{Name: "actions/checkout", Annotation: annotation}
+			if _, ok := uses.AnnotationVersion(); ok {
+				t.Error("Want no version, got one")
+			}
+		})
+	}
+}
+
+func TestUsesComponents(t *testing.T) {
+	type TestCase struct {
+		uses       Uses
+		owner      string
+		repository string
+		path       string
+	}
+
+	testCases := map[string]TestCase{
+		"Remote action": {
+			uses: Uses{
+				Name: "actions/checkout",
+				Ref:  "v5.0.1",
+			},
+			owner:      "actions",
+			repository: "checkout",
+			path:       "",
+		},
+		"Remote action, in subdirectory": {
+			uses: Uses{
+				Name: "github/codeql-action/analyze",
+				Ref:  "main",
+			},
+			owner:      "github",
+			repository: "codeql-action",
+			path:       "analyze",
+		},
+		"Remote action, in nested subdirectory": {
+			uses: Uses{
+				Name: "octo-org/actions/aws/ec2",
+				Ref:  "main",
+			},
+			owner:      "octo-org",
+			repository: "actions",
+			path:       "aws/ec2",
+		},
+		"Remote reusable workflow": {
+			uses: Uses{
+				Name: "octo-org/example-repo/.github/workflows/called-workflow.yml",
+				Ref:  "main",
+			},
+			owner:      "octo-org",
+			repository: "example-repo",
+			path:       ".github/workflows/called-workflow.yml",
+		},
+		"Local action": {
+			uses: Uses{
+				Name: "./.github/actions/ghasum",
+			},
+			owner:      "",
+			repository: "",
+			path:       ".github/actions/ghasum",
+		},
+		"Local action, root": {
+			uses: Uses{
+				Name: ".",
+			},
+			owner:      "",
+			repository: "",
+			path:       "",
+		},
+		"Local reusable workflow": {
+			uses: Uses{
+				Name: "./.github/workflows/called-workflow.yml",
+			},
+			owner:      "",
+			repository: "",
+			path:       ".github/workflows/called-workflow.yml",
+		},
+		"Docker action": {
+			uses: Uses{
+				Name: "docker://gcr.io/cloud-builders/gradle",
+			},
+			owner:      "",
+			repository: "",
+			path:       "",
+		},
+		"Missing name (edge case)": {
+			uses: Uses{
+				Name: "",
+			},
+			owner:      "",
+			repository: "",
+			path:       "",
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got, want := tt.uses.Owner(), tt.owner; got != want {
+				t.Errorf("unexpected owner (got %q, want %q)", got, want)
+			}
+
+			if got, want := tt.uses.Repository(), tt.repository; got != want {
+				t.Errorf("unexpected repository (got %q, want %q)", got, want)
+			}
+
+			if got, want := tt.uses.Path(), tt.path; got != want {
+				t.Errorf("unexpected path (got %q, want %q)", got, want)
+			}
+		})
+	}
+}
+
 func TestUsesString(t *testing.T) {
 	type TestCase struct {
 		uses Uses
@@ -433,6 +1053,44 @@ func TestUsesString(t *testing.T) {
 	}
 }
 
+func TestValueString(t *testing.T) {
+	type TestCase struct {
+		value Value
+		want  string
+	}
+
+	testCases := map[string]TestCase{
+		"String": {
+			value: Value{Kind: ValueString, Raw: "foobar"},
+			want:  "foobar",
+		},
+		"Boolean": {
+			value: Value{Kind: ValueBool, Raw: "true"},
+			want:  "true",
+		},
+		"Integer": {
+			value: Value{Kind: ValueInt, Raw: "0x2A"},
+			want:  "0x2A",
+		},
+		"Float": {
+			value: Value{Kind: ValueFloat, Raw: "3.14"},
+			want:  "3.14",
+		},
+		"Null": {
+			value: Value{Kind: ValueNull, Raw: "~"},
+			want:  "~",
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got, want := tt.value.String(), tt.want; got != want {
+				t.Errorf("unexpected result (got %s, want %s)", got, want)
+			}
+		})
+	}
+}
+
 func checkSteps(t *testing.T, got, want []Step) {
 	t.Helper()
 
@@ -483,7 +1141,7 @@ func checkStep(t *testing.T, got, want *Step) {
 	}
 
 	checkUses(t, &got.Uses, &want.Uses)
-	checkMap(t, got.With, want.With)
+	checkWith(t, got.With, want.With)
 	checkMap(t, got.Env, want.Env)
 }
 
@@ -502,3 +1160,34 @@ func checkUses(t *testing.T, got, want *Uses) {
 		t.Errorf("Unexpected uses annotation (got %q, want %q)", got, want)
 	}
 }
+
+func checkWith(t *testing.T, got, want map[string]Value) {
+	t.Helper()
+
+	if got, want := len(got), len(want); got != want {
+		t.Errorf("Unexpected number of items in with (got %d, want %d)", got, want)
+		return
+	}
+
+	for k, got := range got {
+		want, ok := want[k]
+		if !ok {
+			t.Errorf("Got key %q in with, but do not want it", k)
+			continue
+		}
+
+		if got, want := got.Kind, want.Kind; got != want {
+			t.Errorf("Unexpected kind for key %q in with (got %d, want %d)", k, got, want)
+		}
+
+		if got, want := got.Raw, want.Raw; got != want {
+			t.Errorf("Unexpected value for key %q in with (got %q, want %q)", k, got, want)
+		}
+	}
+
+	for k := range want {
+		if _, ok := got[k]; !ok {
+			t.Errorf("Want key %q in with, but it is not present", k)
+		}
+	}
+}
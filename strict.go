@@ -0,0 +1,309 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package gha
+
+import (
+	"fmt"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// ParseOption configures how a workflow or manifest is parsed.
+type ParseOption func(*parseOptions)
+
+type parseOptions struct {
+	strict bool
+}
+
+// Strict reports keys that are not part of the GitHub Actions schema, e.g. a
+// `timeout-minute:` typo in a job, as errors.
+func Strict() ParseOption {
+	return func(o *parseOptions) {
+		o.strict = true
+	}
+}
+
+// schema describes the keys that are allowed in a YAML object. A nil schema
+// allows anything.
+type schema struct {
+	// keys are the allowed keys of an object and their schema.
+	keys map[string]*schema
+
+	// values is the schema of the values of an object with arbitrary keys.
+	values *schema
+
+	// items is the schema of the items of a list.
+	items *schema
+}
+
+// check returns an error for every key in n, and its children, that is not
+// allowed by s. Values that are not an object or list, e.g. `runs-on: linux`,
+// are not checked.
+func (s *schema) check(n *yaml.Node, path string) Errors {
+	if s == nil {
+		return nil
+	}
+
+	var errs Errors
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+
+			p := k.Value
+			if path != "" {
+				p = path + "." + k.Value
+			}
+
+			switch {
+			case s.values != nil:
+				errs = append(errs, s.values.check(v, p)...)
+			case s.keys != nil:
+				child, ok := s.keys[k.Value]
+				if !ok {
+					errs = append(errs, &Error{
+						Path:    p,
+						Line:    k.Line,
+						Column:  k.Column,
+						Message: fmt.Sprintf("unknown key %q", k.Value),
+					})
+					continue
+				}
+
+				errs = append(errs, child.check(v, p)...)
+			}
+		}
+	case yaml.SequenceNode:
+		for i, v := range n.Content {
+			errs = append(errs, s.items.check(v, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+
+	return errs
+}
+
+// permissionsSchema returns the schema of a `permissions:` object, which allows
+// the known permissions scopes.
+func permissionsSchema() *schema {
+	keys := make(map[string]*schema)
+	for _, scope := range (&Permissions{}).scopes() {
+		keys[scope.name] = nil
+	}
+
+	return object(keys)
+}
+
+func object(keys map[string]*schema) *schema {
+	return &schema{keys: keys}
+}
+
+func mapOf(values *schema) *schema {
+	return &schema{values: values}
+}
+
+func listOf(items *schema) *schema {
+	return &schema{items: items}
+}
+
+var (
+	eventSchema = object(map[string]*schema{
+		"types": nil,
+	})
+
+	pullRequestEventSchema = object(map[string]*schema{
+		"types":           nil,
+		"branches":        nil,
+		"branches-ignore": nil,
+		"paths":           nil,
+		"paths-ignore":    nil,
+	})
+
+	pushEventSchema = object(map[string]*schema{
+		"branches":        nil,
+		"branches-ignore": nil,
+		"tags":            nil,
+		"tags-ignore":     nil,
+		"paths":           nil,
+		"paths-ignore":    nil,
+	})
+
+	outputSchema = object(map[string]*schema{
+		"description": nil,
+		"value":       nil,
+	})
+
+	onSchema = object(map[string]*schema{
+		"branch_protection_rule":      eventSchema,
+		"check_run":                   eventSchema,
+		"check_suite":                 eventSchema,
+		"create":                      eventSchema,
+		"delete":                      eventSchema,
+		"deployment":                  eventSchema,
+		"deployment_status":           eventSchema,
+		"discussion":                  eventSchema,
+		"discussion_comment":          eventSchema,
+		"fork":                        eventSchema,
+		"gollum":                      eventSchema,
+		"issue_comment":               eventSchema,
+		"issues":                      eventSchema,
+		"label":                       eventSchema,
+		"merge_group":                 eventSchema,
+		"milestone":                   eventSchema,
+		"page_build":                  eventSchema,
+		"public":                      eventSchema,
+		"pull_request":                pullRequestEventSchema,
+		"pull_request_review":         eventSchema,
+		"pull_request_review_comment": eventSchema,
+		"pull_request_target":         pullRequestEventSchema,
+		"push":                        pushEventSchema,
+		"registry_package":            eventSchema,
+		"release":                     eventSchema,
+		"repository_dispatch":         eventSchema,
+		"schedule": listOf(object(map[string]*schema{
+			"cron": nil,
+		})),
+		"status": eventSchema,
+		"watch":  eventSchema,
+		"workflow_call": object(map[string]*schema{
+			"inputs": mapOf(object(map[string]*schema{
+				"description": nil,
+				"required":    nil,
+				"default":     nil,
+				"type":        nil,
+			})),
+			"outputs": mapOf(outputSchema),
+			"secrets": mapOf(object(map[string]*schema{
+				"description": nil,
+				"required":    nil,
+			})),
+		}),
+		"workflow_dispatch": object(map[string]*schema{
+			"inputs": mapOf(object(map[string]*schema{
+				"description": nil,
+				"required":    nil,
+				"default":     nil,
+				"type":        nil,
+				"options":     nil,
+			})),
+		}),
+		"workflow_run": object(map[string]*schema{
+			"types":           nil,
+			"workflows":       nil,
+			"branches":        nil,
+			"branches-ignore": nil,
+		}),
+	})
+
+	concurrencySchema = object(map[string]*schema{
+		"group":              nil,
+		"cancel-in-progress": nil,
+	})
+
+	defaultsSchema = object(map[string]*schema{
+		"run": object(map[string]*schema{
+			"shell":             nil,
+			"working-directory": nil,
+		}),
+	})
+
+	serviceSchema = object(map[string]*schema{
+		"image": nil,
+		"credentials": object(map[string]*schema{
+			"username": nil,
+			"password": nil,
+		}),
+		"env":     nil,
+		"ports":   nil,
+		"volumes": nil,
+		"options": nil,
+	})
+
+	stepSchema = object(map[string]*schema{
+		"id":                nil,
+		"if":                nil,
+		"name":              nil,
+		"uses":              nil,
+		"run":               nil,
+		"shell":             nil,
+		"working-directory": nil,
+		"with":              nil,
+		"env":               nil,
+		"continue-on-error": nil,
+		"timeout-minutes":   nil,
+	})
+
+	jobSchema = object(map[string]*schema{
+		"name":        nil,
+		"permissions": permissionsSchema(),
+		"needs":       nil,
+		"if":          nil,
+		"runs-on": object(map[string]*schema{
+			"group":  nil,
+			"labels": nil,
+		}),
+		"environment": object(map[string]*schema{
+			"name": nil,
+			"url":  nil,
+		}),
+		"concurrency":     concurrencySchema,
+		"outputs":         nil,
+		"env":             nil,
+		"defaults":        defaultsSchema,
+		"steps":           listOf(stepSchema),
+		"timeout-minutes": nil,
+		"strategy": object(map[string]*schema{
+			"matrix":       nil,
+			"fail-fast":    nil,
+			"max-parallel": nil,
+		}),
+		"continue-on-error": nil,
+		"container":         serviceSchema,
+		"services":          mapOf(serviceSchema),
+		"uses":              nil,
+		"with":              nil,
+		"secrets":           nil,
+	})
+
+	workflowSchema = object(map[string]*schema{
+		"name":        nil,
+		"run-name":    nil,
+		"on":          onSchema,
+		"permissions": permissionsSchema(),
+		"env":         nil,
+		"defaults":    defaultsSchema,
+		"concurrency": concurrencySchema,
+		"jobs":        mapOf(jobSchema),
+	})
+
+	manifestSchema = object(map[string]*schema{
+		"name":        nil,
+		"author":      nil,
+		"description": nil,
+		"branding": object(map[string]*schema{
+			"color": nil,
+			"icon":  nil,
+		}),
+		"inputs": mapOf(object(map[string]*schema{
+			"description":        nil,
+			"default":            nil,
+			"required":           nil,
+			"deprecationMessage": nil,
+		})),
+		"outputs": mapOf(outputSchema),
+		"runs": object(map[string]*schema{
+			"using":           nil,
+			"steps":           listOf(stepSchema),
+			"image":           nil,
+			"pre-entrypoint":  nil,
+			"entrypoint":      nil,
+			"post-entrypoint": nil,
+			"args":            nil,
+			"env":             nil,
+			"main":            nil,
+			"pre":             nil,
+			"pre-if":          nil,
+			"post":            nil,
+			"post-if":         nil,
+		}),
+	})
+)
@@ -0,0 +1,272 @@
+// SPDX-License-Identifier: BSD-2-Clause
+
+package gha
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseWorkflowStrict(t *testing.T) {
+	type TestCase struct {
+		yaml string
+		want Errors
+	}
+
+	okCases := map[string]TestCase{
+		"Full workflow": {
+			yaml: `
+name: Example
+run-name: Example by ${{ github.actor }}
+on:
+  push:
+    branches: [main]
+    tags-ignore: ['v*']
+  schedule:
+    - cron: '30 5 * * 1'
+  workflow_dispatch:
+    inputs:
+      level:
+        description: Log level
+        type: choice
+        options: [info, debug]
+permissions: read-all
+concurrency:
+  group: ${{ github.ref }}
+  cancel-in-progress: true
+defaults:
+  run:
+    shell: bash
+env:
+  ANYTHING: goes
+jobs:
+  build:
+    name: Build
+    runs-on:
+      group: large
+      labels: [linux]
+    environment:
+      name: production
+      url: https://example.com
+    container:
+      image: node:20
+      credentials:
+        username: octocat
+        password: ${{ secrets.PASSWORD }}
+    services:
+      redis:
+        image: redis
+        ports: [6379]
+    strategy:
+      fail-fast: false
+      matrix:
+        anything: [goes]
+    steps:
+      - id: checkout
+        uses: actions/checkout@v4
+        with:
+          anything: goes
+      - name: Build
+        run: make
+        shell: bash
+        working-directory: src
+        timeout-minutes: 5
+        continue-on-error: true
+  call:
+    needs: [build]
+    uses: ./.github/workflows/called.yml
+    with:
+      anything: goes
+    secrets: inherit
+`,
+		},
+		"Scalar forms": {
+			yaml: `
+on: [push, pull_request]
+concurrency: ci
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    environment: production
+    container: node:20
+`,
+		},
+	}
+
+	for name, tt := range okCases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseWorkflowWithOptions([]byte(tt.yaml), Strict()); err != nil {
+				t.Fatalf("Want no error, got %v", err)
+			}
+		})
+	}
+
+	errCases := map[string]TestCase{
+		"Unknown workflow key": {
+			yaml: `
+on: push
+job: {}
+`,
+			want: Errors{
+				{Path: "job", Line: 3, Column: 1, Message: `unknown key "job"`},
+			},
+		},
+		"Unknown event configuration": {
+			yaml: `
+on:
+  push:
+    branch: [main]
+  schedule:
+    - crom: '30 5 * * 1'
+`,
+			want: Errors{
+				{Path: "on.push.branch", Line: 4, Column: 5, Message: `unknown key "branch"`},
+				{Path: "on.schedule[0].crom", Line: 6, Column: 7, Message: `unknown key "crom"`},
+			},
+		},
+		"Unknown job keys": {
+			yaml: `
+on: push
+jobs:
+  build:
+    runs_on: ubuntu-latest
+    timeout-minute: 5
+`,
+			want: Errors{
+				{Path: "jobs.build.runs_on", Line: 5, Column: 5, Message: `unknown key "runs_on"`},
+				{Path: "jobs.build.timeout-minute", Line: 6, Column: 5, Message: `unknown key "timeout-minute"`},
+			},
+		},
+		"Unknown permissions scope": {
+			yaml: `
+on: push
+permissions:
+  contents: read
+  repository-projects: write
+jobs:
+  build:
+    permissions:
+      issue: write
+`,
+			want: Errors{
+				{Path: "permissions.repository-projects", Line: 5, Column: 3, Message: `unknown key "repository-projects"`},
+				{Path: "jobs.build.permissions.issue", Line: 9, Column: 7, Message: `unknown key "issue"`},
+			},
+		},
+		"Unknown step key": {
+			yaml: `
+on: push
+jobs:
+  build:
+    steps:
+      - run: make
+      - uses: actions/checkout@v4
+        width:
+          fetch-depth: 0
+`,
+			want: Errors{
+				{Path: "jobs.build.steps[1].width", Line: 8, Column: 9, Message: `unknown key "width"`},
+			},
+		},
+		"Unknown key and invalid value": {
+			yaml: `
+on: push
+jobs:
+  build:
+    timeout-minutes: foo
+    step: []
+`,
+			want: Errors{
+				{Path: "jobs.build.timeout-minutes", Line: 5, Column: 22, Message: "cannot unmarshal !!str `foo` into int"},
+				{Path: "jobs.build.step", Line: 6, Column: 5, Message: `unknown key "step"`},
+			},
+		},
+	}
+
+	for name, tt := range errCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseWorkflowWithOptions([]byte(tt.yaml), Strict())
+			if err == nil {
+				t.Fatal("Want an error, got none")
+			}
+
+			var got Errors
+			if !errors.As(err, &got) {
+				t.Fatalf("Want an Errors, got %#v", err)
+			}
+
+			checkErrors(t, got, tt.want)
+		})
+	}
+
+	t.Run("Not strict", func(t *testing.T) {
+		yaml := errCases["Unknown job keys"].yaml
+		if _, err := ParseWorkflowWithOptions([]byte(yaml)); err != nil {
+			t.Errorf("Want no error, got %v", err)
+		}
+	})
+}
+
+func TestParseManifestStrict(t *testing.T) {
+	t.Run("Known keys", func(t *testing.T) {
+		yaml := `
+name: Example
+author: octocat
+description: An example
+branding:
+  color: blue
+  icon: box
+inputs:
+  value:
+    description: A value
+    default: foo
+    required: false
+    deprecationMessage: Do not use
+outputs:
+  result:
+    description: The result
+    value: ${{ steps.run.outputs.result }}
+runs:
+  using: composite
+  steps:
+    - id: run
+      run: echo "result=${{ inputs.value }}" >>"$GITHUB_OUTPUT"
+      shell: bash
+`
+
+		if _, err := ParseManifestWithOptions([]byte(yaml), Strict()); err != nil {
+			t.Fatalf("Want no error, got %v", err)
+		}
+	})
+
+	t.Run("Unknown keys", func(t *testing.T) {
+		yaml := `
+name: Example
+inputs:
+  value:
+    descripton: A value
+runs:
+  using: node20
+  main: index.js
+  post-if: always()
+  pre_if: always()
+`
+
+		_, err := ParseManifestWithOptions([]byte(yaml), Strict())
+		if err == nil {
+			t.Fatal("Want an error, got none")
+		}
+
+		var got Errors
+		if !errors.As(err, &got) {
+			t.Fatalf("Want an Errors, got %#v", err)
+		}
+
+		want := Errors{
+			{Path: "inputs.value.descripton", Line: 5, Column: 5, Message: `unknown key "descripton"`},
+			{Path: "runs.pre_if", Line: 10, Column: 3, Message: `unknown key "pre_if"`},
+		}
+
+		checkErrors(t, got, want)
+	})
+}
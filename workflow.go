@@ -3,9 +3,12 @@
 package gha
 
 import (
+	"bytes"
 	"fmt"
 	"maps"
-	"sort"
+	"reflect"
+	"slices"
+	"strings"
 
 	"go.yaml.in/yaml/v3"
 )
@@ -14,11 +17,20 @@ import (
 type Workflow struct {
 	Name        string            `yaml:"name,omitempty"`
 	RunName     string            `yaml:"run-name,omitempty"`
+	On          On                `yaml:"on"`
 	Permissions Permissions       `yaml:"permissions,omitempty"`
 	Concurrency Concurrency       `yaml:"concurrency,omitempty"`
 	Defaults    Defaults          `yaml:"defaults,omitempty"`
 	Env         map[string]string `yaml:"env,omitempty"`
 	Jobs        map[string]Job    `yaml:"jobs"`
+
+	Extra Extra `yaml:",inline"`
+
+	// Position is the position of the workflow in the YAML document.
+	Position Position `yaml:"-"`
+
+	// Positions are the positions of the keys of the workflow, e.g. "jobs.build".
+	Positions Positions `yaml:"-"`
 }
 
 // Job is a model of a GitHub Actions workflow job.
@@ -32,6 +44,7 @@ type Job struct {
 	Concurrency     Concurrency        `yaml:"concurrency,omitempty"`
 	Defaults        Defaults           `yaml:"defaults,omitempty"`
 	Strategy        Strategy           `yaml:"strategy,omitempty"`
+	Container       Container          `yaml:"container,omitempty"`
 	Services        map[string]Service `yaml:"services,omitempty"`
 	Outputs         map[string]string  `yaml:"outputs,omitempty"`
 	Permissions     Permissions        `yaml:"permissions,omitempty"`
@@ -39,18 +52,43 @@ type Job struct {
 
 	/* step-based job */
 
-	Steps []Step `yaml:"steps,omitempty"`
+	RunsOn RunsOn `yaml:"runs-on,omitempty"`
+	Steps  []Step `yaml:"steps,omitempty"`
 
 	/* uses-based job */
 
-	Uses string            `yaml:"uses,omitempty"`
-	With map[string]string `yaml:"with,omitempty"`
+	Uses    Uses             `yaml:"uses,omitempty"`
+	With    map[string]Value `yaml:"with,omitempty"`
+	Secrets Secrets          `yaml:"secrets,omitempty"`
+
+	Extra Extra `yaml:",inline"`
+
+	// Position is the position of the job in the YAML document.
+	Position Position `yaml:"-"`
+
+	// Positions are the positions of the keys of the job, e.g. "runs-on".
+	Positions Positions `yaml:"-"`
+}
+
+func (j *Job) UnmarshalYAML(n *yaml.Node) error {
+	type job Job
+	if err := n.Decode((*job)(j)); err != nil {
+		return err
+	}
+
+	j.Position = position(n)
+	j.Positions = positions(n)
+	nullValues(n, j.With)
+
+	return nil
 }
 
 // Concurrency is a model of a GitHub Actions `concurrency:` object.
 type Concurrency struct {
 	CancelInProgress string `yaml:"cancel-in-progress,omitempty"`
 	Group            string `yaml:"group,omitempty"`
+
+	Extra Extra `yaml:",inline"`
 }
 
 func (c *Concurrency) UnmarshalYAML(n *yaml.Node) error {
@@ -58,50 +96,128 @@ func (c *Concurrency) UnmarshalYAML(n *yaml.Node) error {
 	case yaml.ScalarNode:
 		c.Group = n.Value
 	case yaml.MappingNode:
-		var concurrency map[string]any
-		_ = n.Decode(&concurrency)
+		var errs []error
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
 
-		if v, ok := concurrency["cancel-in-progress"]; ok {
-			switch v := v.(type) {
-			case bool:
-				c.CancelInProgress = fmt.Sprintf("%t", v)
-			case string:
-				c.CancelInProgress = v
+			switch k.Value {
+			case "cancel-in-progress":
+				var b bool
+				switch v.ShortTag() {
+				case "!!bool":
+					_ = v.Decode(&b)
+					c.CancelInProgress = fmt.Sprintf("%t", b)
+				case "!!str":
+					c.CancelInProgress = v.Value
+				default:
+					errs = append(errs, newError(v, "invalid concurrency.cancel-in-progress %s", v.ShortTag()))
+				}
+			case "group":
+				if v.ShortTag() != "!!str" {
+					errs = append(errs, newError(v, "invalid concurrency.group %s", v.ShortTag()))
+					continue
+				}
+
+				c.Group = v.Value
 			default:
-				return fmt.Errorf("invalid concurrency.cancel-in-progress %v", n.Kind)
+				if c.Extra == nil {
+					c.Extra = make(Extra)
+				}
+
+				c.Extra[k.Value] = *v
 			}
 		}
 
-		if v, ok := concurrency["group"]; ok {
-			v, ok := v.(string)
-			if !ok {
-				return fmt.Errorf("invalid concurrency.group %v", n.Kind)
-			}
+		return joinErrors(errs)
+	default:
+		return newError(n, "invalid concurrency %v", n.Kind)
+	}
+
+	return nil
+}
+
+func (c Concurrency) MarshalYAML() (any, error) {
+	if c.CancelInProgress == "" && len(c.Extra) == 0 {
+		return c.Group, nil
+	}
+
+	cancelInProgress := &yaml.Node{Kind: yaml.ScalarNode, Value: c.CancelInProgress}
+	if c.CancelInProgress != "true" && c.CancelInProgress != "false" {
+		cancelInProgress.Tag = "!!str"
+	}
+
+	concurrency := &yaml.Node{Kind: yaml.MappingNode}
+	if c.Group != "" {
+		concurrency.Content = append(concurrency.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "group"},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: c.Group},
+		)
+	}
+	if c.CancelInProgress != "" {
+		concurrency.Content = append(concurrency.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "cancel-in-progress"},
+			cancelInProgress,
+		)
+	}
+	for _, k := range slices.Sorted(maps.Keys(c.Extra)) {
+		v := c.Extra[k]
+		concurrency.Content = append(concurrency.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: k},
+			&v,
+		)
+	}
 
-			c.Group = v
+	return concurrency, nil
+}
+
+// Container is a model of a GitHub Actions `container:` object.
+type Container Service
+
+func (c *Container) UnmarshalYAML(n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		c.Image = n.Value
+	case yaml.MappingNode:
+		if err := n.Decode((*Service)(c)); err != nil {
+			return err
 		}
 	default:
-		return fmt.Errorf("invalid concurrency %v", n.Kind)
+		return newError(n, "invalid container %v", n.Kind)
 	}
 
 	return nil
 }
 
+func (c Container) MarshalYAML() (any, error) {
+	if reflect.DeepEqual(c.Credentials, ServiceCredentials{}) && len(c.Env) == 0 &&
+		len(c.Ports) == 0 && len(c.Volumes) == 0 && c.Options == "" && len(c.Extra) == 0 {
+		return c.Image, nil
+	}
+
+	return Service(c), nil
+}
+
 // Defaults is a model of a GitHub Actions `defaults:` object.
 type Defaults struct {
 	Run DefaultsRun `yaml:"run,omitempty"`
+
+	Extra Extra `yaml:",inline"`
 }
 
 // DefaultsRun is a model of a GitHub Actions `defaults.run:` object.
 type DefaultsRun struct {
 	Shell            string `yaml:"shell,omitempty"`
 	WorkingDirectory string `yaml:"working-directory,omitempty"`
+
+	Extra Extra `yaml:",inline"`
 }
 
 // Environment is a model of a GitHub Actions `environment:` object.
 type Environment struct {
 	Name string `yaml:"name,omitempty"`
 	Url  string `yaml:"url,omitempty"`
+
+	Extra Extra `yaml:",inline"`
 }
 
 func (e *Environment) UnmarshalYAML(n *yaml.Node) error {
@@ -109,22 +225,27 @@ func (e *Environment) UnmarshalYAML(n *yaml.Node) error {
 	case yaml.ScalarNode:
 		e.Name = n.Value
 	case yaml.MappingNode:
-		var env map[string]string
+		type environment Environment
+		var env environment
 		_ = n.Decode(&env)
 
-		if v, ok := env["name"]; ok {
-			e.Name = v
-		}
-		if v, ok := env["url"]; ok {
-			e.Url = v
-		}
+		*e = Environment(env)
 	default:
-		return fmt.Errorf("invalid environment %q", n.Value)
+		return newError(n, "invalid environment %q", n.Value)
 	}
 
 	return nil
 }
 
+func (e Environment) MarshalYAML() (any, error) {
+	if e.Url == "" && len(e.Extra) == 0 {
+		return e.Name, nil
+	}
+
+	type environment Environment
+	return environment(e), nil
+}
+
 type Needs []string
 
 func (l *Needs) UnmarshalYAML(n *yaml.Node) error {
@@ -139,14 +260,24 @@ func (l *Needs) UnmarshalYAML(n *yaml.Node) error {
 
 		*l = list
 	default:
-		return fmt.Errorf("invalid job.needs %v", n.Kind)
+		return newError(n, "invalid job.needs %v", n.Kind)
 	}
 
 	return nil
 }
 
 // Permissions is a model of a GitHub Actions `permissions:` object.
+//
+// The Mode tells how the permissions are configured. If they are set, every
+// scope has a value of "read", "write" or "none". Scopes that are not known,
+// e.g. ones that GitHub added recently, are kept as is in Extra and can be
+// listed with [Permissions.UnknownScopes], for example to detect typos.
+//
+// When marshalled, `read-all` or `write-all` is only written if every scope
+// has that access, so changing a scope after parsing results in a mapping.
 type Permissions struct {
+	Mode PermissionsMode `yaml:"-"`
+
 	Actions        string `yaml:"actions,omitempty"`
 	Attestations   string `yaml:"attestations,omitempty"`
 	Checks         string `yaml:"checks,omitempty"`
@@ -161,101 +292,319 @@ type Permissions struct {
 	PullRequests   string `yaml:"pull-requests,omitempty"`
 	SecurityEvents string `yaml:"security-events,omitempty"`
 	Statuses       string `yaml:"statuses,omitempty"`
+
+	Extra Extra `yaml:",inline"`
+}
+
+// PermissionsMode is the way in which [Permissions] are configured.
+type PermissionsMode int
+
+const (
+	// PermissionsUnset means that no `permissions:` are set, so the default
+	// permissions apply.
+	PermissionsUnset PermissionsMode = iota
+
+	// PermissionsMapping means that the permissions are set per scope. Scopes
+	// that are not listed have no access.
+	PermissionsMapping
+
+	// PermissionsReadAll means that the permissions are set to `read-all`.
+	PermissionsReadAll
+
+	// PermissionsWriteAll means that the permissions are set to `write-all`.
+	PermissionsWriteAll
+)
+
+// IsSet reports whether the permissions are configured.
+func (p *Permissions) IsSet() bool {
+	return p.Mode != PermissionsUnset
+}
+
+// UnknownScopes returns the names of the scopes in the permissions that are not
+// known, i.e. those in Extra, in lexical order. These scopes do not grant any
+// access in [Workflow.EffectivePermissions].
+func (p *Permissions) UnknownScopes() []string {
+	return slices.Sorted(maps.Keys(p.Extra))
+}
+
+// PermissivePermissions returns the default permissions of a repository whose
+// workflow permissions are set to "Read and write permissions".
+func PermissivePermissions() Permissions {
+	p := Permissions{Mode: PermissionsMapping}
+	for _, scope := range p.scopes() {
+		*scope.value = "write"
+	}
+
+	p.IdToken = "none"
+	p.Models = "read"
+
+	return p
+}
+
+// RestrictedPermissions returns the default permissions of a repository whose
+// workflow permissions are set to "Read repository contents and packages
+// permissions".
+func RestrictedPermissions() Permissions {
+	p := Permissions{Mode: PermissionsMapping}
+	for _, scope := range p.scopes() {
+		*scope.value = "none"
+	}
+
+	p.Contents = "read"
+	p.Packages = "read"
+
+	return p
 }
 
 func (p *Permissions) UnmarshalYAML(n *yaml.Node) error {
+	scopes := p.scopes()
+
 	all := func(s string) {
-		p.Actions = s
-		p.Attestations = s
-		p.Checks = s
-		p.Contents = s
-		p.Deployments = s
-		p.Discussions = s
-		p.IdToken = s
-		p.Issues = s
-		p.Models = s
-		p.Packages = s
-		p.Pages = s
-		p.PullRequests = s
-		p.SecurityEvents = s
-		p.Statuses = s
+		for _, scope := range scopes {
+			*scope.value = s
+		}
 	}
 
 	switch n.Kind {
 	case yaml.ScalarNode:
 		switch n.Value {
 		case "read-all":
+			p.Mode = PermissionsReadAll
 			all("read")
 		case "write-all":
+			p.Mode = PermissionsWriteAll
 			all("write")
 		default:
-			return fmt.Errorf("invalid permissions value %q", n.Value)
+			return newError(n, "invalid permissions value %q", n.Value)
 		}
 	case yaml.MappingNode:
-		var perms map[string]string
-		if err := n.Decode(&perms); err != nil {
-			return err
-		}
-
+		p.Mode = PermissionsMapping
 		all("none")
-		if v, ok := perms["actions"]; ok {
-			p.Actions = v
-		}
-		if v, ok := perms["attestations"]; ok {
-			p.Attestations = v
-		}
-		if v, ok := perms["checks"]; ok {
-			p.Checks = v
-		}
-		if v, ok := perms["contents"]; ok {
-			p.Contents = v
-		}
-		if v, ok := perms["deployments"]; ok {
-			p.Deployments = v
-		}
-		if v, ok := perms["discussions"]; ok {
-			p.Discussions = v
-		}
-		if v, ok := perms["id-token"]; ok {
-			p.IdToken = v
-		}
-		if v, ok := perms["issues"]; ok {
-			p.Issues = v
+
+		var errs []error
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+
+			j := slices.IndexFunc(scopes, func(scope permissionsScope) bool {
+				return scope.name == k.Value
+			})
+			if j == -1 {
+				if p.Extra == nil {
+					p.Extra = make(Extra)
+				}
+
+				p.Extra[k.Value] = *v
+				continue
+			}
+
+			switch {
+			case v.Kind != yaml.ScalarNode:
+				errs = append(errs, newError(v, "invalid permissions.%s %v", k.Value, v.Kind))
+			case v.Value != "read" && v.Value != "write" && v.Value != "none":
+				errs = append(errs, newError(v, "invalid permissions.%s value %q", k.Value, v.Value))
+			default:
+				*scopes[j].value = v.Value
+			}
 		}
-		if v, ok := perms["models"]; ok {
-			p.Models = v
+
+		return joinErrors(errs)
+	default:
+		return newError(n, "invalid permissions %v", n.Kind)
+	}
+
+	return nil
+}
+
+func (p Permissions) MarshalYAML() (any, error) {
+	scopes := p.scopes()
+
+	// Scopes without a value have the access implied by the mode.
+	value := func(scope permissionsScope) string {
+		switch v := *scope.value; {
+		case v != "":
+			return v
+		case p.Mode == PermissionsReadAll:
+			return "read"
+		case p.Mode == PermissionsWriteAll:
+			return "write"
+		default:
+			return ""
 		}
-		if v, ok := perms["issues"]; ok {
-			p.Issues = v
+	}
+
+	all := func(s string) bool {
+		for _, scope := range scopes {
+			if value(scope) != s {
+				return false
+			}
 		}
-		if v, ok := perms["models"]; ok {
-			p.Models = v
+
+		return true
+	}
+
+	switch {
+	case p.Mode == PermissionsMapping, len(p.Extra) > 0:
+	case all("read"):
+		return "read-all", nil
+	case all("write"):
+		return "write-all", nil
+	}
+
+	perms := &yaml.Node{Kind: yaml.MappingNode}
+	for _, scope := range scopes {
+		if v := value(scope); v != "" && v != "none" {
+			perms.Content = append(perms.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: scope.name},
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v},
+			)
 		}
-		if v, ok := perms["issues"]; ok {
-			p.Issues = v
+	}
+	for _, k := range slices.Sorted(maps.Keys(p.Extra)) {
+		v := p.Extra[k]
+		perms.Content = append(perms.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: k},
+			&v,
+		)
+	}
+
+	return perms, nil
+}
+
+type permissionsScope struct {
+	name  string
+	value *string
+}
+
+// scopes returns the name of every permissions scope together with a pointer to
+// its value.
+func (p *Permissions) scopes() []permissionsScope {
+	return []permissionsScope{
+		{"actions", &p.Actions},
+		{"attestations", &p.Attestations},
+		{"checks", &p.Checks},
+		{"contents", &p.Contents},
+		{"deployments", &p.Deployments},
+		{"discussions", &p.Discussions},
+		{"id-token", &p.IdToken},
+		{"issues", &p.Issues},
+		{"models", &p.Models},
+		{"packages", &p.Packages},
+		{"pages", &p.Pages},
+		{"pull-requests", &p.PullRequests},
+		{"security-events", &p.SecurityEvents},
+		{"statuses", &p.Statuses},
+	}
+}
+
+// RunsOn is a model of a GitHub Actions `runs-on:` object.
+type RunsOn struct {
+	Group  string   `yaml:"group,omitempty"`
+	Labels []string `yaml:"labels,omitempty"`
+
+	Extra Extra `yaml:",inline"`
+}
+
+// IsSelfHosted reports whether the job targets self-hosted runners, that is if
+// it has the "self-hosted" label. A runner group can contain self-hosted as well
+// as GitHub-hosted larger runners, so the Group is not taken into account.
+func (r *RunsOn) IsSelfHosted() bool {
+	return slices.Contains(r.Labels, "self-hosted")
+}
+
+func (r *RunsOn) UnmarshalYAML(n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		r.Labels = []string{n.Value}
+	case yaml.SequenceNode:
+		var list []string
+		if err := n.Decode(&list); err != nil {
+			return err
 		}
-		if v, ok := perms["packages"]; ok {
-			p.Packages = v
+
+		r.Labels = list
+	case yaml.MappingNode:
+		var runsOn struct {
+			Group  string    `yaml:"group"`
+			Labels yaml.Node `yaml:"labels"`
+			Extra  Extra     `yaml:",inline"`
 		}
-		if v, ok := perms["pages"]; ok {
-			p.Pages = v
+		if err := n.Decode(&runsOn); err != nil {
+			return err
 		}
-		if v, ok := perms["pull-requests"]; ok {
-			p.PullRequests = v
+
+		r.Group = runsOn.Group
+		r.Extra = runsOn.Extra
+		switch runsOn.Labels.Kind {
+		case 0:
+		case yaml.ScalarNode:
+			r.Labels = []string{runsOn.Labels.Value}
+		case yaml.SequenceNode:
+			if err := runsOn.Labels.Decode(&r.Labels); err != nil {
+				return err
+			}
+		default:
+			return newError(&runsOn.Labels, "invalid runs-on.labels %v", runsOn.Labels.Kind)
 		}
-		if v, ok := perms["security-events"]; ok {
-			p.SecurityEvents = v
+	default:
+		return newError(n, "invalid runs-on %v", n.Kind)
+	}
+
+	return nil
+}
+
+func (r RunsOn) MarshalYAML() (any, error) {
+	switch {
+	case len(r.Extra) > 0:
+	case r.Group == "" && len(r.Labels) == 1:
+		return r.Labels[0], nil
+	case r.Group == "":
+		return r.Labels, nil
+	}
+
+	type runsOn RunsOn
+	return runsOn(r), nil
+}
+
+// Secrets is a model of a GitHub Actions job `secrets:` object.
+type Secrets struct {
+	// Inherit is set if all secrets are passed to the called workflow, i.e.
+	// `secrets: inherit`.
+	Inherit bool
+
+	// Values are the secrets that are explicitly passed to the called workflow.
+	Values map[string]string
+}
+
+func (s *Secrets) UnmarshalYAML(n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		if n.Value != "inherit" {
+			return newError(n, "invalid secrets value %q", n.Value)
 		}
-		if v, ok := perms["statuses"]; ok {
-			p.Statuses = v
+
+		s.Inherit = true
+	case yaml.MappingNode:
+		var secrets map[string]string
+		if err := n.Decode(&secrets); err != nil {
+			return err
 		}
+
+		s.Values = secrets
 	default:
-		return fmt.Errorf("invalid permissions %q", n.Value)
+		return newError(n, "invalid secrets %v", n.Kind)
 	}
 
 	return nil
 }
 
+func (s Secrets) MarshalYAML() (any, error) {
+	if s.Inherit {
+		return "inherit", nil
+	}
+
+	return s.Values, nil
+}
+
 // Service is a model of a GitHub Actions `services:` object.
 type Service struct {
 	Image       string             `yaml:"image"`
@@ -264,6 +613,8 @@ type Service struct {
 	Ports       Ports              `yaml:"ports,omitempty"`
 	Volumes     []string           `yaml:"volumes,omitempty"`
 	Options     string             `yaml:"options,omitempty"`
+
+	Extra Extra `yaml:",inline"`
 }
 
 type Ports []string
@@ -272,149 +623,406 @@ type Ports []string
 type ServiceCredentials struct {
 	Username string `yaml:"username"`
 	Password string `yaml:"password"`
+
+	Extra Extra `yaml:",inline"`
 }
 
 // Strategy is a model of a GitHub Actions `strategy:` object.
 type Strategy struct {
-	Matrix      Matrix `yaml:"matrix,omitempty"`
-	FailFast    bool   `yaml:"fail-fast,omitempty"`
-	MaxParallel int    `yaml:"max-parallel,omitempty"`
+	Matrix Matrix `yaml:"matrix,omitempty"`
+
+	// FailFast is the `fail-fast:` value, or nil if it is not set. See
+	// [Strategy.IsFailFast] for its effective value.
+	FailFast *bool `yaml:"fail-fast,omitempty"`
+
+	MaxParallel int `yaml:"max-parallel,omitempty"`
+
+	Extra Extra `yaml:",inline"`
+}
+
+// IsFailFast reports whether all in-progress jobs of the matrix are cancelled if
+// one of them fails, which is the default.
+func (s *Strategy) IsFailFast() bool {
+	return s.FailFast == nil || *s.FailFast
 }
 
 // Matrix is a model of a GitHub Actions `strategy.matrix:` object.
-type Matrix []map[string]any
+type Matrix struct {
+	// Expression is the expression that defines the matrix, if it is dynamic,
+	// e.g. `${{ fromJSON(needs.setup.outputs.matrix) }}`.
+	Expression string
 
-func (m *Matrix) UnmarshalYAML(n *yaml.Node) error {
-	if n.Kind != yaml.MappingNode {
-		return fmt.Errorf("invalid matrix %q", n.Value)
-	}
+	// Axes are the variables of the matrix, in the order they are declared.
+	Axes []MatrixAxis
 
-	var raw map[string]any
-	_ = n.Decode(&raw)
+	// Include are the `include:` entries of the matrix.
+	Include []map[string]any
 
-	var include []map[string]any
-	if v, ok := raw["include"]; ok {
-		delete(raw, "include")
+	// IncludeExpression is the expression that defines the `include:` entries,
+	// if they are dynamic.
+	IncludeExpression string
 
-		tmp, ok := v.([]any)
-		if !ok {
-			return fmt.Errorf("invalid matrix.include %v", v)
-		}
+	// Exclude are the `exclude:` entries of the matrix.
+	Exclude []map[string]any
 
-		include = make([]map[string]any, len(tmp))
-		for k, v := range tmp {
-			if v, ok := v.(map[string]any); !ok {
-				return fmt.Errorf("invalid matrix.include entry %v", v)
-			} else {
-				include[k] = v
-			}
-		}
-	}
+	// ExcludeExpression is the expression that defines the `exclude:` entries,
+	// if they are dynamic.
+	ExcludeExpression string
+}
 
-	var exclude []map[string]any
-	if v, ok := raw["exclude"]; ok {
-		delete(raw, "exclude")
+// MatrixAxis is a model of a variable of a [Matrix].
+type MatrixAxis struct {
+	Name   string
+	Values []any
 
-		tmp, ok := v.([]any)
-		if !ok {
-			return fmt.Errorf("invalid matrix.exclude %v", v)
-		}
+	// Expression is the expression that defines the values, if they are dynamic.
+	Expression string
+}
 
-		exclude = make([]map[string]any, len(tmp))
-		for k, v := range tmp {
-			if v, ok := v.(map[string]any); !ok {
-				return fmt.Errorf("invalid matrix.exclude entry %v", v)
-			} else {
-				exclude[k] = v
-			}
+// IsDynamic reports whether (part of) the matrix is defined by an expression.
+func (m *Matrix) IsDynamic() bool {
+	if m.Expression != "" || m.IncludeExpression != "" || m.ExcludeExpression != "" {
+		return true
+	}
+
+	for _, axis := range m.Axes {
+		if axis.Expression != "" {
+			return true
 		}
 	}
 
+	return false
+}
+
+// Combinations returns the job configurations described by the matrix using
+// the same semantics as GitHub. That is, `exclude:` entries are applied before
+// `include:` entries. An `include:` entry is added to every combination whose
+// original matrix values it does not overwrite, or becomes a new combination if
+// there are none.
+//
+// The parts of a dynamic matrix are not evaluated. An axis defined by an
+// expression has the expression as its only value, and `include:` or
+// `exclude:` entries defined by an expression are ignored. If the whole matrix
+// is defined by an expression there are no combinations.
+func (m *Matrix) Combinations() []map[string]any {
+	if m.Expression != "" {
+		return nil
+	}
+
 	result := []map[string]any{}
-	if len(raw) != 0 {
+	if len(m.Axes) != 0 {
 		result = append(result, map[string]any{})
 	}
-	for k, tmp := range raw {
-		var vs []any
-		switch tmp := tmp.(type) {
-		case []any:
-			vs = tmp
-		case string:
-			vs = []any{tmp}
-		default:
-			return fmt.Errorf("invalid matrix entry %q", k)
+	for _, axis := range m.Axes {
+		values := axis.Values
+		if axis.Expression != "" {
+			values = []any{axis.Expression}
 		}
 
 		matrix := []map[string]any{}
-		for _, v := range vs {
-			for _, src := range result {
+		for _, src := range result {
+			for _, v := range values {
 				dest := map[string]any{}
 				matrix = append(matrix, dest)
 
 				maps.Copy(dest, src)
-				dest[k] = v
+				dest[axis.Name] = v
 			}
 		}
 
 		result = matrix
 	}
 
-	extend := []map[string]any{}
-Loop_include:
-	for _, include := range include {
-		for _, entry := range result {
-			found := entry
-			for k, want := range entry {
-				if got, ok := include[k]; !ok || got != want {
-					found = nil
-				}
+	matches := func(entry, filter map[string]any) bool {
+		for k, want := range filter {
+			if got, ok := entry[k]; !ok || !reflect.DeepEqual(got, want) {
+				return false
 			}
+		}
 
-			if found != nil {
-				for k, v := range include {
-					found[k] = v
-				}
+		return true
+	}
+
+	result = slices.DeleteFunc(result, func(entry map[string]any) bool {
+		return slices.ContainsFunc(m.Exclude, func(exclude map[string]any) bool {
+			return matches(entry, exclude)
+		})
+	})
+
+	original := len(result)
+	for _, include := range m.Include {
+		// An include entry may not overwrite any of the original matrix values.
+		filter := map[string]any{}
+		for _, axis := range m.Axes {
+			if v, ok := include[axis.Name]; ok {
+				filter[axis.Name] = v
+			}
+		}
 
-				continue Loop_include
+		added := false
+		for _, entry := range result[:original] {
+			if matches(entry, filter) {
+				maps.Copy(entry, include)
+				added = true
 			}
 		}
 
-		extend = append(extend, include)
+		if !added {
+			result = append(result, maps.Clone(include))
+		}
 	}
-	result = append(result, extend...)
 
-	for _, exclude := range exclude {
-		omit := []int{}
-		for i, entry := range result {
-			found := true
-			for k, want := range exclude {
-				if got, ok := entry[k]; !ok || got != want {
-					found = false
-				}
+	return result
+}
+
+func (m *Matrix) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode && isExpression(n.Value) {
+		m.Expression = n.Value
+		return nil
+	}
+
+	if n.Kind != yaml.MappingNode {
+		return newError(n, "invalid matrix %q", n.Value)
+	}
+
+	entries := func(n *yaml.Node, name string) ([]map[string]any, error) {
+		var raw any
+		_ = n.Decode(&raw)
+
+		tmp, ok := raw.([]any)
+		if !ok {
+			return nil, newError(n, "invalid matrix.%s %v", name, raw)
+		}
+
+		var errs []error
+		entries := make([]map[string]any, len(tmp))
+		for k, v := range tmp {
+			if entry, ok := v.(map[string]any); !ok {
+				errs = append(errs, newError(n.Content[k], "invalid matrix.%s entry %v", name, v))
+			} else {
+				entries[k] = entry
 			}
+		}
+
+		return entries, joinErrors(errs)
+	}
+
+	var errs []error
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+
+		dynamic := v.Kind == yaml.ScalarNode && isExpression(v.Value)
 
-			if found {
-				omit = append(omit, i)
+		var err error
+		switch {
+		case k.Value == "include" && dynamic:
+			m.IncludeExpression = v.Value
+		case k.Value == "include":
+			m.Include, err = entries(v, k.Value)
+		case k.Value == "exclude" && dynamic:
+			m.ExcludeExpression = v.Value
+		case k.Value == "exclude":
+			m.Exclude, err = entries(v, k.Value)
+		case dynamic:
+			m.Axes = append(m.Axes, MatrixAxis{Name: k.Value, Expression: v.Value})
+		default:
+			var raw any
+			_ = v.Decode(&raw)
+
+			var vs []any
+			switch raw := raw.(type) {
+			case []any:
+				vs = raw
+			case string:
+				vs = []any{raw}
+			default:
+				err = newError(v, "invalid matrix entry %q", k.Value)
 			}
+
+			m.Axes = append(m.Axes, MatrixAxis{Name: k.Value, Values: vs})
+		}
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return joinErrors(errs)
+}
+
+func (m Matrix) MarshalYAML() (any, error) {
+	if m.Expression != "" {
+		return m.Expression, nil
+	}
+
+	matrix := &yaml.Node{Kind: yaml.MappingNode}
+	add := func(k string, v any) error {
+		var value yaml.Node
+		if err := value.Encode(v); err != nil {
+			return err
+		}
+
+		matrix.Content = append(matrix.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: k},
+			&value,
+		)
+
+		return nil
+	}
+
+	for _, axis := range m.Axes {
+		var values any = axis.Values
+		if axis.Expression != "" {
+			values = axis.Expression
+		}
+
+		if err := add(axis.Name, values); err != nil {
+			return nil, err
 		}
+	}
+
+	switch {
+	case m.IncludeExpression != "":
+		if err := add("include", m.IncludeExpression); err != nil {
+			return nil, err
+		}
+	case m.Include != nil:
+		if err := add("include", m.Include); err != nil {
+			return nil, err
+		}
+	}
 
-		sort.Sort(sort.Reverse(sort.IntSlice(omit)))
-		for _, i := range omit {
-			result = append(result[0:i], result[i+1:]...)
+	switch {
+	case m.ExcludeExpression != "":
+		if err := add("exclude", m.ExcludeExpression); err != nil {
+			return nil, err
 		}
+	case m.Exclude != nil:
+		if err := add("exclude", m.Exclude); err != nil {
+			return nil, err
+		}
+	}
+
+	return matrix, nil
+}
+
+// IsReusable reports whether the workflow can be called from other workflows.
+func (w *Workflow) IsReusable() bool {
+	return w.On.WorkflowCall != nil
+}
+
+// EffectivePermissions returns the permissions of the `GITHUB_TOKEN` for the
+// job with the given id. Permissions of the job replace those of the workflow
+// entirely, and if neither is set the repository's defaults apply. See also
+// [PermissivePermissions] and [RestrictedPermissions].
+//
+// The second return value is false if the workflow has no job with the id.
+func (w *Workflow) EffectivePermissions(id string, defaults Permissions) (Permissions, bool) {
+	job, ok := w.Jobs[id]
+	switch {
+	case !ok:
+		return Permissions{}, false
+	case job.Permissions.IsSet():
+		return job.Permissions, true
+	case w.Permissions.IsSet():
+		return w.Permissions, true
+	default:
+		return defaults, true
 	}
+}
 
-	*m = result
+func (w *Workflow) UnmarshalYAML(n *yaml.Node) error {
+	type workflow Workflow
+	if err := n.Decode((*workflow)(w)); err != nil {
+		return err
+	}
+
+	w.Position = position(n)
+	w.Positions = positions(n)
 
 	return nil
 }
 
+func (w Workflow) MarshalYAML() (any, error) {
+	type workflow Workflow
+
+	// Go through the textual representation to preserve comments, which are not
+	// retained by [yaml.Node.Encode].
+	data, err := yaml.Marshal(workflow(w))
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	// Avoid quoting the `on` key, which is a boolean in YAML 1.1.
+	n := doc.Content[0]
+	for i := 0; i < len(n.Content); i += 2 {
+		if k := n.Content[i]; k.Value == "on" {
+			k.Style = 0
+		}
+	}
+
+	return n, nil
+}
+
+// Bytes returns the YAML representation of the workflow.
+func (w *Workflow) Bytes() ([]byte, error) {
+	data, err := marshal(w)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal workflow: %v", err)
+	}
+
+	return data, nil
+}
+
 // ParseWorkflow parses a GitHub Actions workflow into a [Workflow].
 func ParseWorkflow(data []byte) (Workflow, error) {
+	return ParseWorkflowWithOptions(data)
+}
+
+// ParseWorkflowWithOptions parses a GitHub Actions workflow into a [Workflow] using the
+// given options.
+func ParseWorkflowWithOptions(data []byte, opts ...ParseOption) (Workflow, error) {
+	var options parseOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var s *schema
+	if options.strict {
+		s = workflowSchema
+	}
+
 	var workflow Workflow
-	if err := yaml.Unmarshal(data, &workflow); err != nil {
-		return workflow, fmt.Errorf("could not parse workflow: %v", err)
+	if err := unmarshal(data, &workflow, s); err != nil {
+		return workflow, fmt.Errorf("could not parse workflow: %w", err)
 	}
 
 	return workflow, nil
 }
+
+func marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// isExpression reports whether the value is a GitHub Actions expression.
+func isExpression(v string) bool {
+	v = strings.TrimSpace(v)
+	return strings.HasPrefix(v, "${{") && strings.HasSuffix(v, "}}")
+}
@@ -70,6 +70,25 @@ jobs: {}
 				},
 			},
 		},
+		"Workflow triggers": {
+			yaml: `
+on:
+    push:
+        branches: [main]
+    pull_request: ~
+    workflow_dispatch:
+jobs: {}
+`,
+			model: Workflow{
+				On: On{
+					PullRequest: &PullRequestEvent{},
+					Push: &PushEvent{
+						Branches: []string{"main"},
+					},
+					WorkflowDispatch: &WorkflowDispatchEvent{},
+				},
+			},
+		},
 		"Job metadata": {
 			yaml: `
 jobs:
@@ -155,7 +174,7 @@ jobs:
 							},
 						},
 						Strategy: Strategy{
-							FailFast:    true,
+							FailFast:    ptr(true),
 							MaxParallel: 10,
 						},
 						Services: map[string]Service{
@@ -182,6 +201,8 @@ jobs:
 							"output2": "${{ steps.step2.outputs.test }}",
 						},
 						Permissions: Permissions{
+							Mode: PermissionsMapping,
+
 							Actions:        "none",
 							Attestations:   "none",
 							Checks:         "none",
@@ -222,6 +243,8 @@ jobs:
 							},
 						},
 						Permissions: Permissions{
+							Mode: PermissionsMapping,
+
 							Actions:        "none",
 							Attestations:   "write",
 							Checks:         "none",
@@ -273,8 +296,8 @@ jobs:
 									Name: "actions/checkout",
 									Ref:  "v3",
 								},
-								With: map[string]string{
-									"persist-credentials": "false",
+								With: map[string]Value{
+									"persist-credentials": {Raw: "false"},
 								},
 							},
 							{
@@ -282,8 +305,8 @@ jobs:
 									Name: "actions/setup-node",
 									Ref:  "v3",
 								},
-								With: map[string]string{
-									"node-version": "20",
+								With: map[string]Value{
+									"node-version": {Raw: "20"},
 								},
 							},
 							{
@@ -296,8 +319,8 @@ jobs:
 									Name: "actions/github-script",
 									Ref:  "v6",
 								},
-								With: map[string]string{
-									"script": "console.log('${{ inputs.value }}')",
+								With: map[string]Value{
+									"script": {Raw: "console.log('${{ inputs.value }}')"},
 								},
 							},
 						},
@@ -305,331 +328,248 @@ jobs:
 				},
 			},
 		},
-		"Job with 'uses:'": {
+		"Job with 'runs-on:'": {
 			yaml: `
 jobs:
-    example:
-        uses: octo-org/example-repo/.github/workflows/called-workflow.yml@main
-        with:
-            foo: bar
+    label:
+        runs-on: ubuntu-latest
+    labels:
+        runs-on: [self-hosted, linux, x64]
+    group:
+        runs-on:
+            group: ubuntu-runners
+    group-and-label:
+        runs-on:
+            group: ubuntu-runners
+            labels: ubuntu-20.04-16core
+    group-and-labels:
+        runs-on:
+            group: ubuntu-runners
+            labels: [self-hosted, linux]
+    expression:
+        runs-on: ${{ matrix.os }}
 `,
 			model: Workflow{
 				Jobs: map[string]Job{
-					"example": {
-						Uses: "octo-org/example-repo/.github/workflows/called-workflow.yml@main",
-						With: map[string]string{"foo": "bar"},
+					"label": {
+						RunsOn: RunsOn{
+							Labels: []string{"ubuntu-latest"},
+						},
 					},
-				},
-			},
-		},
-		"Workflow with only some permissions": {
-			yaml: `
-permissions:
-    actions: read
-    checks: write
-    packages: write
-    statuses: read
-jobs:
-    example:
-        steps:
-            - uses: actions/checkout@v4
-`,
-			model: Workflow{
-				Permissions: Permissions{
-					Actions:        "read",
-					Attestations:   "none",
-					Checks:         "write",
-					Contents:       "none",
-					Deployments:    "none",
-					Discussions:    "none",
-					IdToken:        "none",
-					Issues:         "none",
-					Models:         "none",
-					Packages:       "write",
-					Pages:          "none",
-					PullRequests:   "none",
-					SecurityEvents: "none",
-					Statuses:       "read",
-				},
-				Jobs: map[string]Job{
-					"example": {
-						Steps: []Step{
-							{
-								Uses: Uses{
-									Name: "actions/checkout",
-									Ref:  "v4",
-								},
-							},
+					"labels": {
+						RunsOn: RunsOn{
+							Labels: []string{"self-hosted", "linux", "x64"},
 						},
 					},
-				},
-			},
-		},
-		"Job matrix, one dimensional matrix": {
-			yaml: `
-jobs:
-    job:
-        strategy:
-            matrix:
-                version:
-                    - 10
-                    - 12
-                    - 14
-`,
-			model: Workflow{
-				Jobs: map[string]Job{
-					"job": {
-						Strategy: Strategy{
-							Matrix: []map[string]any{
-								{"version": 10},
-								{"version": 12},
-								{"version": 14},
-							},
+					"group": {
+						RunsOn: RunsOn{
+							Group: "ubuntu-runners",
+						},
+					},
+					"group-and-label": {
+						RunsOn: RunsOn{
+							Group:  "ubuntu-runners",
+							Labels: []string{"ubuntu-20.04-16core"},
+						},
+					},
+					"group-and-labels": {
+						RunsOn: RunsOn{
+							Group:  "ubuntu-runners",
+							Labels: []string{"self-hosted", "linux"},
+						},
+					},
+					"expression": {
+						RunsOn: RunsOn{
+							Labels: []string{"${{ matrix.os }}"},
 						},
 					},
 				},
 			},
 		},
-		"Job matrix, two dimensional matrix": {
+		"Job with 'container:'": {
 			yaml: `
 jobs:
-    job:
-        strategy:
-            matrix:
-                os:
-                    - ubuntu-22.04
-                    - ubuntu-24.04
-                version:
-                    - 10
-                    - 12
-                    - 14
+    image:
+        container: node:18
+    object:
+        container:
+            image: ghcr.io/owner/image
+            credentials:
+                username: ${{ github.actor }}
+                password: ${{ secrets.github_token }}
+            env:
+                NODE_ENV: development
+            ports:
+                - 80
+            volumes:
+                - my_docker_volume:/volume_mount
+            options: --cpus 1
 `,
 			model: Workflow{
 				Jobs: map[string]Job{
-					"job": {
-						Strategy: Strategy{
-							Matrix: []map[string]any{
-								{"os": "ubuntu-22.04", "version": 10},
-								{"os": "ubuntu-24.04", "version": 10},
-								{"os": "ubuntu-22.04", "version": 12},
-								{"os": "ubuntu-24.04", "version": 12},
-								{"os": "ubuntu-22.04", "version": 14},
-								{"os": "ubuntu-24.04", "version": 14},
+					"image": {
+						Container: Container{
+							Image: "node:18",
+						},
+					},
+					"object": {
+						Container: Container{
+							Image: "ghcr.io/owner/image",
+							Credentials: ServiceCredentials{
+								Username: "${{ github.actor }}",
+								Password: "${{ secrets.github_token }}",
+							},
+							Env: map[string]string{
+								"NODE_ENV": "development",
+							},
+							Ports: []string{
+								"80",
 							},
+							Volumes: []string{
+								"my_docker_volume:/volume_mount",
+							},
+							Options: "--cpus 1",
 						},
 					},
 				},
 			},
 		},
-		"Job matrix, nested values matrix": {
+		"Job with 'uses:'": {
 			yaml: `
 jobs:
-    job:
-        strategy:
-            matrix:
-                node:
-                    - version: 14
-                    - env: NODE_OPTIONS=--openssl-legacy-provider
-                      version: 20
-                os:
-                    - ubuntu-latest
-                    - macos-latest
+    example:
+        uses: octo-org/example-repo/.github/workflows/called-workflow.yml@main
+        with:
+            foo: bar
+            dry-run: true
+            retries: 3
+            token: ~
 `,
 			model: Workflow{
 				Jobs: map[string]Job{
-					"job": {
-						Strategy: Strategy{
-							Matrix: []map[string]any{
-								{
-									"os": "ubuntu-latest",
-									"node": map[string]any{
-										"version": 14,
-									},
-								},
-								{
-									"os": "ubuntu-latest",
-									"node": map[string]any{
-										"version": 20,
-										"env":     "NODE_OPTIONS=--openssl-legacy-provider",
-									},
-								},
-								{
-									"os": "macos-latest",
-									"node": map[string]any{
-										"version": 14,
-									},
-								},
-								{
-									"os": "macos-latest",
-									"node": map[string]any{
-										"version": 20,
-										"env":     "NODE_OPTIONS=--openssl-legacy-provider",
-									},
-								},
-							},
+					"example": {
+						Uses: Uses{
+							Name: "octo-org/example-repo/.github/workflows/called-workflow.yml",
+							Ref:  "main",
+						},
+						With: map[string]Value{
+							"foo":     {Kind: ValueString, Raw: "bar"},
+							"dry-run": {Kind: ValueBool, Raw: "true"},
+							"retries": {Kind: ValueInt, Raw: "3"},
+							"token":   {Kind: ValueNull, Raw: "~"},
 						},
 					},
 				},
 			},
 		},
-		"Job matrix, context matrix": {
+		"Job with local 'uses:'": {
 			yaml: `
 jobs:
-    job:
-        strategy:
-            matrix:
-                version: ${{ github.event.client_payload.versions }}
+    example:
+        uses: ./.github/workflows/called-workflow.yml
 `,
 			model: Workflow{
 				Jobs: map[string]Job{
-					"job": {
-						Strategy: Strategy{
-							Matrix: []map[string]any{
-								{
-									"version": "${{ github.event.client_payload.versions }}",
-								},
-							},
+					"example": {
+						Uses: Uses{
+							Name: "./.github/workflows/called-workflow.yml",
 						},
 					},
 				},
 			},
 		},
-		"Job matrix, matrix include": {
+		"Job with annotated 'uses:'": {
 			yaml: `
 jobs:
-    job:
-        strategy:
-            matrix:
-                animal:
-                    - cat
-                    - dog
-                fruit:
-                    - apple
-                    - pear
-                include:
-                    - color: green
-                    - animal: cat
-                      color: pink
-                    - fruit: apple
-                      shape: circle
-                    - fruit: banana
-                    - animal: cat
-                      fruit: banana
+    example:
+        uses: octo-org/example-repo/.github/workflows/called-workflow.yml@8f4b7f84864484a7bf31766abe9204da3cbe65b3 # v1.2.3
 `,
 			model: Workflow{
 				Jobs: map[string]Job{
-					"job": {
-						Strategy: Strategy{
-							Matrix: []map[string]any{
-								{
-									"animal": "cat",
-									"fruit":  "apple",
-								},
-								{
-									"animal": "cat",
-									"fruit":  "pear",
-								},
-								{
-									"animal": "dog",
-									"fruit":  "apple",
-								},
-								{
-									"animal": "dog",
-									"fruit":  "pear",
-								},
-								{
-									"color": "green",
-								},
-								{
-									"animal": "cat",
-									"color":  "pink",
-								},
-								{
-									"fruit": "apple",
-									"shape": "circle",
-								},
-								{
-									"fruit": "banana",
-								},
-								{
-									"animal": "cat",
-									"fruit":  "banana",
-								},
-							},
+					"example": {
+						Uses: Uses{
+							Name:       "octo-org/example-repo/.github/workflows/called-workflow.yml",
+							Ref:        "8f4b7f84864484a7bf31766abe9204da3cbe65b3",
+							Annotation: "v1.2.3",
 						},
 					},
 				},
 			},
 		},
-		"Job matrix, expanding configuration": {
+		"Job with 'secrets:'": {
 			yaml: `
 jobs:
-    job:
-        strategy:
-            matrix:
-                include:
-                    - node: 16
-                      npm: 6
-                      os: windows-latest
-                node:
-                    - 14
-                    - 16
-                os:
-                    - windows-latest
-                    - ubuntu-latest
+    inherit:
+        uses: octo-org/example-repo/.github/workflows/called-workflow.yml@main
+        secrets: inherit
+    explicit:
+        uses: octo-org/example-repo/.github/workflows/called-workflow.yml@main
+        secrets:
+            access-token: ${{ secrets.PERSONAL_ACCESS_TOKEN }}
 `,
 			model: Workflow{
 				Jobs: map[string]Job{
-					"job": {
-						Strategy: Strategy{
-							Matrix: []map[string]any{
-								{
-									"node": 14,
-									"os":   "windows-latest",
-								},
-								{
-									"node": 14,
-									"os":   "ubuntu-latest",
-								},
-								{
-									"node": 16,
-									"npm":  6,
-									"os":   "windows-latest",
-								},
-								{
-									"node": 16,
-									"os":   "ubuntu-latest",
-								},
+					"inherit": {
+						Uses: Uses{
+							Name: "octo-org/example-repo/.github/workflows/called-workflow.yml",
+							Ref:  "main",
+						},
+						Secrets: Secrets{
+							Inherit: true,
+						},
+					},
+					"explicit": {
+						Uses: Uses{
+							Name: "octo-org/example-repo/.github/workflows/called-workflow.yml",
+							Ref:  "main",
+						},
+						Secrets: Secrets{
+							Values: map[string]string{
+								"access-token": "${{ secrets.PERSONAL_ACCESS_TOKEN }}",
 							},
 						},
 					},
 				},
 			},
 		},
-		"Job matrix, include only": {
+		"Workflow with only some permissions": {
 			yaml: `
+permissions:
+    actions: read
+    checks: write
+    packages: write
+    statuses: read
 jobs:
-    job:
-        strategy:
-            matrix:
-                include:
-                    - datacenter: site-a
-                      site: production
-                    - datacenter: site-b
-                      site: staging
+    example:
+        steps:
+            - uses: actions/checkout@v4
 `,
 			model: Workflow{
+				Permissions: Permissions{
+					Mode: PermissionsMapping,
+
+					Actions:        "read",
+					Attestations:   "none",
+					Checks:         "write",
+					Contents:       "none",
+					Deployments:    "none",
+					Discussions:    "none",
+					IdToken:        "none",
+					Issues:         "none",
+					Models:         "none",
+					Packages:       "write",
+					Pages:          "none",
+					PullRequests:   "none",
+					SecurityEvents: "none",
+					Statuses:       "read",
+				},
 				Jobs: map[string]Job{
-					"job": {
-						Strategy: Strategy{
-							Matrix: []map[string]any{
-								{
-									"datacenter": "site-a",
-									"site":       "production",
-								},
-								{
-									"datacenter": "site-b",
-									"site":       "staging",
+					"example": {
+						Steps: []Step{
+							{
+								Uses: Uses{
+									Name: "actions/checkout",
+									Ref:  "v4",
 								},
 							},
 						},
@@ -637,78 +577,39 @@ jobs:
 				},
 			},
 		},
-		"Job matrix, exclude": {
+		"Job matrix": {
 			yaml: `
 jobs:
     job:
         strategy:
             matrix:
-                environment:
-                    - staging
-                    - production
-                exclude:
-                    - environment: production
-                      os: macos-latest
-                      version: 12
-                    - os: windows-latest
-                      version: 16
                 os:
-                    - macos-latest
-                    - windows-latest
+                    - ubuntu-22.04
+                    - ubuntu-24.04
                 version:
+                    - 10
                     - 12
-                    - 14
-                    - 16
+                include:
+                    - os: windows-latest
+                      version: 12
+                exclude:
+                    - os: ubuntu-22.04
+                      version: 12
 `,
 			model: Workflow{
 				Jobs: map[string]Job{
 					"job": {
 						Strategy: Strategy{
-							Matrix: []map[string]any{
-								{
-									"environment": "staging",
-									"os":          "macos-latest",
-									"version":     12,
-								},
-								{
-									"environment": "staging",
-									"os":          "macos-latest",
-									"version":     14,
-								},
-								{
-									"environment": "staging",
-									"os":          "macos-latest",
-									"version":     16,
-								},
-								{
-									"environment": "staging",
-									"os":          "windows-latest",
-									"version":     12,
+							Matrix: Matrix{
+								Axes: []MatrixAxis{
+									{Name: "os", Values: []any{"ubuntu-22.04", "ubuntu-24.04"}},
+									{Name: "version", Values: []any{10, 12}},
 								},
-								{
-									"environment": "staging",
-									"os":          "windows-latest",
-									"version":     14,
+								Include: []map[string]any{
+									{"os": "windows-latest", "version": 12},
 								},
-								{
-									"environment": "production",
-									"os":          "macos-latest",
-									"version":     14,
-								},
-								{
-									"environment": "production",
-									"os":          "macos-latest",
-									"version":     16,
-								},
-								{
-									"environment": "production",
-									"os":          "windows-latest",
-									"version":     12,
-								},
-								{
-									"environment": "production",
-									"os":          "windows-latest",
-									"version":     14,
+								Exclude: []map[string]any{
+									{"os": "ubuntu-22.04", "version": 12},
 								},
 							},
 						},
@@ -726,6 +627,8 @@ jobs:
 `,
 			model: Workflow{
 				Permissions: Permissions{
+					Mode: PermissionsReadAll,
+
 					Actions:        "read",
 					Attestations:   "read",
 					Checks:         "read",
@@ -765,6 +668,8 @@ jobs:
 `,
 			model: Workflow{
 				Permissions: Permissions{
+					Mode: PermissionsWriteAll,
+
 					Actions:        "write",
 					Attestations:   "write",
 					Checks:         "write",
@@ -804,6 +709,8 @@ jobs:
 `,
 			model: Workflow{
 				Permissions: Permissions{
+					Mode: PermissionsMapping,
+
 					Actions:        "none",
 					Attestations:   "none",
 					Checks:         "none",
@@ -899,6 +806,12 @@ name:
 run-name:
 - foo
 - bar
+`,
+		},
+		"invalid 'on' value": {
+			yaml: `
+on:
+    push: [3, 14]
 `,
 		},
 		"invalid 'permissions' value, scalar": {
@@ -917,6 +830,12 @@ permissions:
 			yaml: `
 permissions:
     issues: [3, 14]
+`,
+		},
+		"invalid 'permissions.[*]' value, unknown level": {
+			yaml: `
+permissions:
+    issues: admin
 `,
 		},
 		"invalid 'concurrency' value": {
@@ -1133,6 +1052,29 @@ jobs:
   example:
     strategy:
       max-parallel: [42]
+`,
+		},
+		"invalid job 'container' value": {
+			yaml: `
+jobs:
+  example:
+    container: [3, 14]
+`,
+		},
+		"invalid job 'container.image' value": {
+			yaml: `
+jobs:
+  example:
+    container:
+      image: [3, 14]
+`,
+		},
+		"invalid job 'container.credentials' value": {
+			yaml: `
+jobs:
+  example:
+    container:
+      credentials: foobar
 `,
 		},
 		"invalid job 'services' value": {
@@ -1244,6 +1186,31 @@ jobs:
 jobs:
   example:
     env: foobar
+`,
+		},
+		"invalid job 'runs-on' value": {
+			yaml: `
+jobs:
+  example:
+    runs-on:
+      - [3, 14]
+`,
+		},
+		"invalid job 'runs-on.group' value": {
+			yaml: `
+jobs:
+  example:
+    runs-on:
+      group: [3, 14]
+`,
+		},
+		"invalid job 'runs-on.labels' value": {
+			yaml: `
+jobs:
+  example:
+    runs-on:
+      labels:
+        foo: bar
 `,
 		},
 		"invalid job 'steps' value": {
@@ -1268,12 +1235,1528 @@ jobs:
     with: foobar
 `,
 		},
-	}
+		"invalid job 'secrets' value, scalar": {
+			yaml: `
+jobs:
+  example:
+    secrets: foobar
+`,
+		},
+		"invalid job 'secrets' value, non-scalar": {
+			yaml: `
+jobs:
+  example:
+    secrets: [3, 14]
+`,
+		},
+		"invalid job 'secrets.[*]' value": {
+			yaml: `
+jobs:
+  example:
+    secrets:
+      foo: [3, 14]
+`,
+		},
+	}
+
+	for name, tt := range errCases {
+		t.Run(name, func(t *testing.T) {
+			if err := yaml.Unmarshal([]byte(tt.yaml), &tt.model); err == nil {
+				t.Error("Want an error, got none")
+			}
+		})
+	}
+}
+
+func TestMatrix(t *testing.T) {
+	type TestCase struct {
+		yaml         string
+		model        Matrix
+		combinations []map[string]any
+	}
+
+	okCases := map[string]TestCase{
+		"One dimensional matrix": {
+			yaml: `
+version:
+    - 10
+    - 12
+    - 14
+`,
+			model: Matrix{
+				Axes: []MatrixAxis{
+					{Name: "version", Values: []any{10, 12, 14}},
+				},
+			},
+			combinations: []map[string]any{
+				{"version": 10},
+				{"version": 12},
+				{"version": 14},
+			},
+		},
+		"Two dimensional matrix": {
+			yaml: `
+os:
+    - ubuntu-22.04
+    - ubuntu-24.04
+version:
+    - 10
+    - 12
+    - 14
+`,
+			model: Matrix{
+				Axes: []MatrixAxis{
+					{Name: "os", Values: []any{"ubuntu-22.04", "ubuntu-24.04"}},
+					{Name: "version", Values: []any{10, 12, 14}},
+				},
+			},
+			combinations: []map[string]any{
+				{"os": "ubuntu-22.04", "version": 10},
+				{"os": "ubuntu-24.04", "version": 10},
+				{"os": "ubuntu-22.04", "version": 12},
+				{"os": "ubuntu-24.04", "version": 12},
+				{"os": "ubuntu-22.04", "version": 14},
+				{"os": "ubuntu-24.04", "version": 14},
+			},
+		},
+		"Nested values matrix": {
+			yaml: `
+node:
+    - version: 14
+    - env: NODE_OPTIONS=--openssl-legacy-provider
+      version: 20
+os:
+    - ubuntu-latest
+    - macos-latest
+`,
+			model: Matrix{
+				Axes: []MatrixAxis{
+					{
+						Name: "node",
+						Values: []any{
+							map[string]any{
+								"version": 14,
+							},
+							map[string]any{
+								"env":     "NODE_OPTIONS=--openssl-legacy-provider",
+								"version": 20,
+							},
+						},
+					},
+					{Name: "os", Values: []any{"ubuntu-latest", "macos-latest"}},
+				},
+			},
+			combinations: []map[string]any{
+				{
+					"os": "ubuntu-latest",
+					"node": map[string]any{
+						"version": 14,
+					},
+				},
+				{
+					"os": "ubuntu-latest",
+					"node": map[string]any{
+						"version": 20,
+						"env":     "NODE_OPTIONS=--openssl-legacy-provider",
+					},
+				},
+				{
+					"os": "macos-latest",
+					"node": map[string]any{
+						"version": 14,
+					},
+				},
+				{
+					"os": "macos-latest",
+					"node": map[string]any{
+						"version": 20,
+						"env":     "NODE_OPTIONS=--openssl-legacy-provider",
+					},
+				},
+			},
+		},
+		"Context matrix": {
+			yaml: `
+version: ${{ github.event.client_payload.versions }}
+`,
+			model: Matrix{
+				Axes: []MatrixAxis{
+					{Name: "version", Expression: "${{ github.event.client_payload.versions }}"},
+				},
+			},
+			combinations: []map[string]any{
+				{
+					"version": "${{ github.event.client_payload.versions }}",
+				},
+			},
+		},
+		"Expression matrix": {
+			yaml: `${{ fromJSON(needs.setup.outputs.matrix) }}`,
+			model: Matrix{
+				Expression: "${{ fromJSON(needs.setup.outputs.matrix) }}",
+			},
+			combinations: nil,
+		},
+		"Expression include and exclude": {
+			yaml: `
+os:
+    - ubuntu-latest
+    - windows-latest
+include: ${{ fromJSON(needs.setup.outputs.include) }}
+exclude: ${{ fromJSON(needs.setup.outputs.exclude) }}
+`,
+			model: Matrix{
+				Axes: []MatrixAxis{
+					{Name: "os", Values: []any{"ubuntu-latest", "windows-latest"}},
+				},
+				IncludeExpression: "${{ fromJSON(needs.setup.outputs.include) }}",
+				ExcludeExpression: "${{ fromJSON(needs.setup.outputs.exclude) }}",
+			},
+			combinations: []map[string]any{
+				{"os": "ubuntu-latest"},
+				{"os": "windows-latest"},
+			},
+		},
+		"Matrix include": {
+			yaml: `
+animal:
+    - cat
+    - dog
+fruit:
+    - apple
+    - pear
+include:
+    - color: green
+    - animal: cat
+      color: pink
+    - fruit: apple
+      shape: circle
+    - fruit: banana
+    - animal: cat
+      fruit: banana
+`,
+			model: Matrix{
+				Axes: []MatrixAxis{
+					{Name: "animal", Values: []any{"cat", "dog"}},
+					{Name: "fruit", Values: []any{"apple", "pear"}},
+				},
+				Include: []map[string]any{
+					{"color": "green"},
+					{"animal": "cat", "color": "pink"},
+					{"fruit": "apple", "shape": "circle"},
+					{"fruit": "banana"},
+					{"animal": "cat", "fruit": "banana"},
+				},
+			},
+			combinations: []map[string]any{
+				{"animal": "cat", "color": "pink", "fruit": "apple", "shape": "circle"},
+				{"animal": "cat", "color": "pink", "fruit": "pear"},
+				{"animal": "dog", "color": "green", "fruit": "apple", "shape": "circle"},
+				{"animal": "dog", "color": "green", "fruit": "pear"},
+				{"fruit": "banana"},
+				{"animal": "cat", "fruit": "banana"},
+			},
+		},
+		"Expanding configuration": {
+			yaml: `
+include:
+    - node: 16
+      npm: 6
+      os: windows-latest
+node:
+    - 14
+    - 16
+os:
+    - windows-latest
+    - ubuntu-latest
+`,
+			model: Matrix{
+				Axes: []MatrixAxis{
+					{Name: "node", Values: []any{14, 16}},
+					{Name: "os", Values: []any{"windows-latest", "ubuntu-latest"}},
+				},
+				Include: []map[string]any{
+					{"node": 16, "npm": 6, "os": "windows-latest"},
+				},
+			},
+			combinations: []map[string]any{
+				{
+					"node": 14,
+					"os":   "windows-latest",
+				},
+				{
+					"node": 14,
+					"os":   "ubuntu-latest",
+				},
+				{
+					"node": 16,
+					"npm":  6,
+					"os":   "windows-latest",
+				},
+				{
+					"node": 16,
+					"os":   "ubuntu-latest",
+				},
+			},
+		},
+		"Include only": {
+			yaml: `
+include:
+    - datacenter: site-a
+      site: production
+    - datacenter: site-b
+      site: staging
+`,
+			model: Matrix{
+				Include: []map[string]any{
+					{"datacenter": "site-a", "site": "production"},
+					{"datacenter": "site-b", "site": "staging"},
+				},
+			},
+			combinations: []map[string]any{
+				{
+					"datacenter": "site-a",
+					"site":       "production",
+				},
+				{
+					"datacenter": "site-b",
+					"site":       "staging",
+				},
+			},
+		},
+		"Exclude": {
+			yaml: `
+environment:
+    - staging
+    - production
+exclude:
+    - environment: production
+      os: macos-latest
+      version: 12
+    - os: windows-latest
+      version: 16
+os:
+    - macos-latest
+    - windows-latest
+version:
+    - 12
+    - 14
+    - 16
+`,
+			model: Matrix{
+				Axes: []MatrixAxis{
+					{Name: "environment", Values: []any{"staging", "production"}},
+					{Name: "os", Values: []any{"macos-latest", "windows-latest"}},
+					{Name: "version", Values: []any{12, 14, 16}},
+				},
+				Exclude: []map[string]any{
+					{"environment": "production", "os": "macos-latest", "version": 12},
+					{"os": "windows-latest", "version": 16},
+				},
+			},
+			combinations: []map[string]any{
+				{
+					"environment": "staging",
+					"os":          "macos-latest",
+					"version":     12,
+				},
+				{
+					"environment": "staging",
+					"os":          "macos-latest",
+					"version":     14,
+				},
+				{
+					"environment": "staging",
+					"os":          "macos-latest",
+					"version":     16,
+				},
+				{
+					"environment": "staging",
+					"os":          "windows-latest",
+					"version":     12,
+				},
+				{
+					"environment": "staging",
+					"os":          "windows-latest",
+					"version":     14,
+				},
+				{
+					"environment": "production",
+					"os":          "macos-latest",
+					"version":     14,
+				},
+				{
+					"environment": "production",
+					"os":          "macos-latest",
+					"version":     16,
+				},
+				{
+					"environment": "production",
+					"os":          "windows-latest",
+					"version":     12,
+				},
+				{
+					"environment": "production",
+					"os":          "windows-latest",
+					"version":     14,
+				},
+			},
+		},
+	}
+
+	for name, tt := range okCases {
+		t.Run(name, func(t *testing.T) {
+			var got Matrix
+			if err := yaml.Unmarshal([]byte(tt.yaml), &got); err != nil {
+				t.Fatalf("Want no error, got %#v", err)
+			}
+
+			want := tt.model
+			checkMatrix(t, &got, &want)
+			checkCombinations(t, got.Combinations(), tt.combinations)
+		})
+	}
+}
+
+func TestMatrixCombinations(t *testing.T) {
+	type TestCase struct {
+		matrix Matrix
+		want   []map[string]any
+	}
+
+	testCases := map[string]TestCase{
+		"Single-dimension matrix": {
+			matrix: Matrix{
+				Axes: []MatrixAxis{
+					{Name: "version", Values: []any{10, 12, 14}},
+				},
+			},
+			want: []map[string]any{
+				{"version": 10},
+				{"version": 12},
+				{"version": 14},
+			},
+		},
+		"Multi-dimension matrix": {
+			matrix: Matrix{
+				Axes: []MatrixAxis{
+					{Name: "os", Values: []any{"ubuntu-22.04", "ubuntu-20.04"}},
+					{Name: "version", Values: []any{10, 12, 14}},
+				},
+			},
+			want: []map[string]any{
+				{"os": "ubuntu-22.04", "version": 10},
+				{"os": "ubuntu-22.04", "version": 12},
+				{"os": "ubuntu-22.04", "version": 14},
+				{"os": "ubuntu-20.04", "version": 10},
+				{"os": "ubuntu-20.04", "version": 12},
+				{"os": "ubuntu-20.04", "version": 14},
+			},
+		},
+		"Expanding or adding matrix configurations": {
+			matrix: Matrix{
+				Axes: []MatrixAxis{
+					{Name: "fruit", Values: []any{"apple", "pear"}},
+					{Name: "animal", Values: []any{"cat", "dog"}},
+				},
+				Include: []map[string]any{
+					{"color": "green"},
+					{"color": "pink", "animal": "cat"},
+					{"fruit": "apple", "shape": "circle"},
+					{"fruit": "banana"},
+					{"fruit": "banana", "animal": "cat"},
+				},
+			},
+			want: []map[string]any{
+				{"fruit": "apple", "animal": "cat", "color": "pink", "shape": "circle"},
+				{"fruit": "apple", "animal": "dog", "color": "green", "shape": "circle"},
+				{"fruit": "pear", "animal": "cat", "color": "pink"},
+				{"fruit": "pear", "animal": "dog", "color": "green"},
+				{"fruit": "banana"},
+				{"fruit": "banana", "animal": "cat"},
+			},
+		},
+		"Expanding configurations": {
+			matrix: Matrix{
+				Axes: []MatrixAxis{
+					{Name: "os", Values: []any{"windows-latest", "ubuntu-latest"}},
+					{Name: "node", Values: []any{14, 16}},
+				},
+				Include: []map[string]any{
+					{"os": "windows-latest", "node": 16, "npm": 6},
+				},
+			},
+			want: []map[string]any{
+				{"os": "windows-latest", "node": 14},
+				{"os": "windows-latest", "node": 16, "npm": 6},
+				{"os": "ubuntu-latest", "node": 14},
+				{"os": "ubuntu-latest", "node": 16},
+			},
+		},
+		"Adding configurations": {
+			matrix: Matrix{
+				Axes: []MatrixAxis{
+					{Name: "os", Values: []any{"windows-latest", "ubuntu-latest"}},
+					{Name: "node", Values: []any{14, 16}},
+				},
+				Include: []map[string]any{
+					{"os": "windows-latest", "node": 17},
+				},
+			},
+			want: []map[string]any{
+				{"os": "windows-latest", "node": 14},
+				{"os": "windows-latest", "node": 16},
+				{"os": "ubuntu-latest", "node": 14},
+				{"os": "ubuntu-latest", "node": 16},
+				{"os": "windows-latest", "node": 17},
+			},
+		},
+		"Include only": {
+			matrix: Matrix{
+				Include: []map[string]any{
+					{"site": "production", "datacenter": "site-a"},
+					{"site": "staging", "datacenter": "site-b"},
+				},
+			},
+			want: []map[string]any{
+				{"site": "production", "datacenter": "site-a"},
+				{"site": "staging", "datacenter": "site-b"},
+			},
+		},
+		"Excluding matrix configurations": {
+			matrix: Matrix{
+				Axes: []MatrixAxis{
+					{Name: "os", Values: []any{"macos-latest", "windows-latest"}},
+					{Name: "version", Values: []any{12, 14, 16}},
+					{Name: "environment", Values: []any{"staging", "production"}},
+				},
+				Exclude: []map[string]any{
+					{"os": "macos-latest", "version": 12, "environment": "production"},
+					{"os": "windows-latest", "version": 16},
+				},
+			},
+			want: []map[string]any{
+				{"os": "macos-latest", "version": 12, "environment": "staging"},
+				{"os": "macos-latest", "version": 14, "environment": "staging"},
+				{"os": "macos-latest", "version": 14, "environment": "production"},
+				{"os": "macos-latest", "version": 16, "environment": "staging"},
+				{"os": "macos-latest", "version": 16, "environment": "production"},
+				{"os": "windows-latest", "version": 12, "environment": "staging"},
+				{"os": "windows-latest", "version": 12, "environment": "production"},
+				{"os": "windows-latest", "version": 14, "environment": "staging"},
+				{"os": "windows-latest", "version": 14, "environment": "production"},
+			},
+		},
+		"Include after exclude": {
+			matrix: Matrix{
+				Axes: []MatrixAxis{
+					{Name: "os", Values: []any{"macos-latest", "windows-latest"}},
+					{Name: "version", Values: []any{12, 14}},
+				},
+				Exclude: []map[string]any{
+					{"os": "windows-latest", "version": 12},
+				},
+				Include: []map[string]any{
+					{"os": "windows-latest", "version": 12, "experimental": true},
+				},
+			},
+			want: []map[string]any{
+				{"os": "macos-latest", "version": 12},
+				{"os": "macos-latest", "version": 14},
+				{"os": "windows-latest", "version": 14},
+				{"os": "windows-latest", "version": 12, "experimental": true},
+			},
+		},
+		"Object values": {
+			matrix: Matrix{
+				Axes: []MatrixAxis{
+					{
+						Name: "os",
+						Values: []any{
+							map[string]any{"name": "linux", "runner": "ubuntu-latest"},
+							map[string]any{"name": "windows", "runner": "windows-latest"},
+						},
+					},
+					{Name: "node", Values: []any{[]any{14, 16}, []any{18}}},
+				},
+				Exclude: []map[string]any{
+					{"node": []any{18}, "os": map[string]any{"name": "windows", "runner": "windows-latest"}},
+				},
+				Include: []map[string]any{
+					{"os": map[string]any{"name": "linux", "runner": "ubuntu-latest"}, "shell": "bash"},
+					{"os": map[string]any{"name": "linux"}, "shell": "sh"},
+				},
+			},
+			want: []map[string]any{
+				{"os": map[string]any{"name": "linux", "runner": "ubuntu-latest"}, "node": []any{14, 16}, "shell": "bash"},
+				{"os": map[string]any{"name": "linux", "runner": "ubuntu-latest"}, "node": []any{18}, "shell": "bash"},
+				{"os": map[string]any{"name": "windows", "runner": "windows-latest"}, "node": []any{14, 16}},
+				{"os": map[string]any{"name": "linux"}, "shell": "sh"},
+			},
+		},
+		"Include does not overwrite original values": {
+			matrix: Matrix{
+				Axes: []MatrixAxis{
+					{Name: "os", Values: []any{"ubuntu-latest"}},
+				},
+				Include: []map[string]any{
+					{"os": "windows-latest", "experimental": true},
+				},
+			},
+			want: []map[string]any{
+				{"os": "ubuntu-latest"},
+				{"os": "windows-latest", "experimental": true},
+			},
+		},
+		"Include overwrites added values": {
+			matrix: Matrix{
+				Axes: []MatrixAxis{
+					{Name: "os", Values: []any{"ubuntu-latest", "windows-latest"}},
+				},
+				Include: []map[string]any{
+					{"shell": "bash"},
+					{"os": "windows-latest", "shell": "pwsh"},
+				},
+			},
+			want: []map[string]any{
+				{"os": "ubuntu-latest", "shell": "bash"},
+				{"os": "windows-latest", "shell": "pwsh"},
+			},
+		},
+		"Expression matrix": {
+			matrix: Matrix{
+				Expression: "${{ fromJSON(needs.setup.outputs.matrix) }}",
+			},
+			want: nil,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			checkCombinations(t, tt.matrix.Combinations(), tt.want)
+		})
+	}
+}
+
+func TestMatrixIsDynamic(t *testing.T) {
+	type TestCase struct {
+		matrix Matrix
+		want   bool
+	}
+
+	testCases := map[string]TestCase{
+		"Static matrix": {
+			matrix: Matrix{
+				Axes: []MatrixAxis{
+					{Name: "os", Values: []any{"ubuntu-latest"}},
+				},
+				Include: []map[string]any{
+					{"os": "windows-latest"},
+				},
+			},
+			want: false,
+		},
+		"Expression matrix": {
+			matrix: Matrix{
+				Expression: "${{ fromJSON(needs.setup.outputs.matrix) }}",
+			},
+			want: true,
+		},
+		"Expression axis": {
+			matrix: Matrix{
+				Axes: []MatrixAxis{
+					{Name: "os", Values: []any{"ubuntu-latest"}},
+					{Name: "version", Expression: "${{ fromJSON(inputs.versions) }}"},
+				},
+			},
+			want: true,
+		},
+		"Expression include": {
+			matrix: Matrix{
+				IncludeExpression: "${{ fromJSON(inputs.include) }}",
+			},
+			want: true,
+		},
+		"Expression exclude": {
+			matrix: Matrix{
+				ExcludeExpression: "${{ fromJSON(inputs.exclude) }}",
+			},
+			want: true,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got, want := tt.matrix.IsDynamic(), tt.want; got != want {
+				t.Errorf("unexpected result (got %t, want %t)", got, want)
+			}
+		})
+	}
+}
+
+func TestWorkflowIsReusable(t *testing.T) {
+	type TestCase struct {
+		workflow Workflow
+		want     bool
+	}
+
+	testCases := map[string]TestCase{
+		"No triggers": {
+			workflow: Workflow{},
+			want:     false,
+		},
+		"Not callable": {
+			workflow: Workflow{
+				On: On{
+					Push:             &PushEvent{},
+					WorkflowDispatch: &WorkflowDispatchEvent{},
+				},
+			},
+			want: false,
+		},
+		"Only callable": {
+			workflow: Workflow{
+				On: On{
+					WorkflowCall: &WorkflowCallEvent{},
+				},
+			},
+			want: true,
+		},
+		"Callable and other triggers": {
+			workflow: Workflow{
+				On: On{
+					Push:         &PushEvent{},
+					WorkflowCall: &WorkflowCallEvent{},
+				},
+			},
+			want: true,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got, want := tt.workflow.IsReusable(), tt.want; got != want {
+				t.Errorf("unexpected result (got %t, want %t)", got, want)
+			}
+		})
+	}
+}
+
+func TestWorkflowEffectivePermissions(t *testing.T) {
+	type TestCase struct {
+		yaml     string
+		job      string
+		defaults Permissions
+		want     Permissions
+	}
+
+	none := Permissions{Mode: PermissionsMapping}
+	for _, scope := range none.scopes() {
+		*scope.value = "none"
+	}
+
+	withScopes := func(p Permissions, f func(p *Permissions)) Permissions {
+		f(&p)
+		return p
+	}
+
+	testCases := map[string]TestCase{
+		"Repository default, restricted": {
+			yaml: `
+jobs:
+    example:
+        runs-on: ubuntu-latest
+`,
+			job:      "example",
+			defaults: RestrictedPermissions(),
+			want: withScopes(none, func(p *Permissions) {
+				p.Contents = "read"
+				p.Packages = "read"
+			}),
+		},
+		"Repository default, permissive": {
+			yaml: `
+jobs:
+    example:
+        runs-on: ubuntu-latest
+`,
+			job:      "example",
+			defaults: PermissivePermissions(),
+			want: Permissions{
+				Mode:           PermissionsMapping,
+				Actions:        "write",
+				Attestations:   "write",
+				Checks:         "write",
+				Contents:       "write",
+				Deployments:    "write",
+				Discussions:    "write",
+				IdToken:        "none",
+				Issues:         "write",
+				Models:         "read",
+				Packages:       "write",
+				Pages:          "write",
+				PullRequests:   "write",
+				SecurityEvents: "write",
+				Statuses:       "write",
+			},
+		},
+		"Workflow permissions": {
+			yaml: `
+permissions:
+    issues: write
+jobs:
+    example:
+        runs-on: ubuntu-latest
+`,
+			job:      "example",
+			defaults: PermissivePermissions(),
+			want: withScopes(none, func(p *Permissions) {
+				p.Issues = "write"
+			}),
+		},
+		"Job permissions": {
+			yaml: `
+permissions: write-all
+jobs:
+    example:
+        runs-on: ubuntu-latest
+        permissions:
+            contents: read
+`,
+			job:      "example",
+			defaults: PermissivePermissions(),
+			want: withScopes(none, func(p *Permissions) {
+				p.Contents = "read"
+			}),
+		},
+		"Job without permissions": {
+			yaml: `
+permissions:
+    issues: write
+jobs:
+    example:
+        runs-on: ubuntu-latest
+        permissions:
+            contents: read
+    other:
+        runs-on: ubuntu-latest
+`,
+			job:      "other",
+			defaults: PermissivePermissions(),
+			want: withScopes(none, func(p *Permissions) {
+				p.Issues = "write"
+			}),
+		},
+		"Empty job permissions": {
+			yaml: `
+permissions: read-all
+jobs:
+    example:
+        runs-on: ubuntu-latest
+        permissions: {}
+`,
+			job:      "example",
+			defaults: PermissivePermissions(),
+			want:     none,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			workflow, err := ParseWorkflow([]byte(tt.yaml))
+			if err != nil {
+				t.Fatalf("Want no error, got %#v", err)
+			}
+
+			got, ok := workflow.EffectivePermissions(tt.job, tt.defaults)
+			if !ok {
+				t.Fatalf("Want job %q to exist", tt.job)
+			}
+
+			checkPermissions(t, &got, &tt.want)
+		})
+	}
+
+	t.Run("Unknown job", func(t *testing.T) {
+		workflow := Workflow{
+			Permissions: Permissions{Mode: PermissionsReadAll},
+		}
+
+		if _, ok := workflow.EffectivePermissions("missing", PermissivePermissions()); ok {
+			t.Error("Want no permissions for a job that does not exist")
+		}
+	})
+}
+
+func TestPermissionsIsSet(t *testing.T) {
+	type TestCase struct {
+		yaml string
+		mode PermissionsMode
+	}
+
+	testCases := map[string]TestCase{
+		"Unset": {
+			yaml: `
+jobs: {}
+`,
+			mode: PermissionsUnset,
+		},
+		"Empty mapping": {
+			yaml: `
+permissions: {}
+`,
+			mode: PermissionsMapping,
+		},
+		"Mapping": {
+			yaml: `
+permissions:
+    contents: read
+`,
+			mode: PermissionsMapping,
+		},
+		"Read all": {
+			yaml: `
+permissions: read-all
+`,
+			mode: PermissionsReadAll,
+		},
+		"Write all": {
+			yaml: `
+permissions: write-all
+`,
+			mode: PermissionsWriteAll,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			workflow, err := ParseWorkflow([]byte(tt.yaml))
+			if err != nil {
+				t.Fatalf("Want no error, got %#v", err)
+			}
+
+			if got, want := workflow.Permissions.Mode, tt.mode; got != want {
+				t.Errorf("Unexpected permissions mode (got %d, want %d)", got, want)
+			}
+
+			if got, want := workflow.Permissions.IsSet(), tt.mode != PermissionsUnset; got != want {
+				t.Errorf("unexpected result (got %t, want %t)", got, want)
+			}
+		})
+	}
+}
+
+func TestPermissionsUnknownScopes(t *testing.T) {
+	type TestCase struct {
+		yaml string
+		want []string
+	}
+
+	testCases := map[string]TestCase{
+		"Unset": {
+			yaml: `
+jobs: {}
+`,
+			want: nil,
+		},
+		"Read all": {
+			yaml: `
+permissions: read-all
+`,
+			want: nil,
+		},
+		"Known scopes": {
+			yaml: `
+permissions:
+    contents: read
+    issues: write
+`,
+			want: nil,
+		},
+		"Typo": {
+			yaml: `
+permissions:
+    contents: read
+    issue: write
+`,
+			want: []string{"issue"},
+		},
+		"Multiple unknown scopes": {
+			yaml: `
+permissions:
+    repository-projects: write
+    content: read
+`,
+			want: []string{"content", "repository-projects"},
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			workflow, err := ParseWorkflow([]byte(tt.yaml))
+			if err != nil {
+				t.Fatalf("Want no error, got %#v", err)
+			}
+
+			if got, want := workflow.Permissions.UnknownScopes(), tt.want; !slices.Equal(got, want) {
+				t.Errorf("unexpected result (got %v, want %v)", got, want)
+			}
+		})
+	}
+}
+
+func TestRunsOnIsSelfHosted(t *testing.T) {
+	type TestCase struct {
+		runsOn RunsOn
+		want   bool
+	}
+
+	testCases := map[string]TestCase{
+		"GitHub-hosted runner": {
+			runsOn: RunsOn{
+				Labels: []string{"ubuntu-latest"},
+			},
+			want: false,
+		},
+		"Self-hosted runner": {
+			runsOn: RunsOn{
+				Labels: []string{"self-hosted", "linux"},
+			},
+			want: true,
+		},
+		"Runner group": {
+			runsOn: RunsOn{
+				Group: "larger-ubuntu-runners",
+			},
+			want: false,
+		},
+		"Runner group with self-hosted label": {
+			runsOn: RunsOn{
+				Group:  "ubuntu-runners",
+				Labels: []string{"self-hosted"},
+			},
+			want: true,
+		},
+		"Expression": {
+			runsOn: RunsOn{
+				Labels: []string{"${{ matrix.os }}"},
+			},
+			want: false,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got, want := tt.runsOn.IsSelfHosted(), tt.want; got != want {
+				t.Errorf("unexpected result (got %t, want %t)", got, want)
+			}
+		})
+	}
+}
+
+func TestStrategyIsFailFast(t *testing.T) {
+	type TestCase struct {
+		strategy Strategy
+		want     bool
+	}
+
+	testCases := map[string]TestCase{
+		"Unset": {
+			strategy: Strategy{},
+			want:     true,
+		},
+		"Enabled": {
+			strategy: Strategy{FailFast: ptr(true)},
+			want:     true,
+		},
+		"Disabled": {
+			strategy: Strategy{FailFast: ptr(false)},
+			want:     false,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got, want := tt.strategy.IsFailFast(), tt.want; got != want {
+				t.Errorf("unexpected result (got %t, want %t)", got, want)
+			}
+		})
+	}
+}
+
+func TestWorkflowBytes(t *testing.T) {
+	type TestCase struct {
+		model Workflow
+		yaml  string
+	}
+
+	testCases := map[string]TestCase{
+		"Workflow metadata": {
+			model: Workflow{
+				Name:    "Example workflow",
+				RunName: "Example run by @${{ github.actor }}",
+				On: On{
+					Push: &PushEvent{
+						Branches: []string{"main"},
+					},
+					WorkflowDispatch: &WorkflowDispatchEvent{},
+				},
+				Concurrency: Concurrency{
+					Group: "group A",
+				},
+				Defaults: Defaults{
+					Run: DefaultsRun{
+						Shell: "bash",
+					},
+				},
+				Env:  map[string]string{"FOO": "true"},
+				Jobs: map[string]Job{},
+			},
+			yaml: `name: Example workflow
+run-name: Example run by @${{ github.actor }}
+on:
+  push:
+    branches:
+      - main
+  workflow_dispatch:
+concurrency: group A
+defaults:
+  run:
+    shell: bash
+env:
+  FOO: "true"
+jobs: {}
+`,
+		},
+		"Job metadata": {
+			model: Workflow{
+				Jobs: map[string]Job{
+					"example": {
+						Name: "Example",
+						Environment: Environment{
+							Name: "foo-env",
+						},
+						Needs: []string{"other"},
+						Concurrency: Concurrency{
+							CancelInProgress: "true",
+							Group:            "group B",
+						},
+						Strategy: Strategy{
+							Matrix: Matrix{
+								Axes: []MatrixAxis{
+									{Name: "os", Values: []any{"ubuntu-latest"}},
+									{Name: "node", Expression: "${{ fromJSON(inputs.versions) }}"},
+								},
+							},
+						},
+						Container: Container{
+							Image: "node:18",
+						},
+						Services: map[string]Service{
+							"redis": {
+								Image: "redis",
+								Ports: []string{"6379/tcp"},
+							},
+						},
+						RunsOn: RunsOn{
+							Labels: []string{"ubuntu-latest"},
+						},
+					},
+					"other": {
+						Environment: Environment{
+							Name: "bar-env",
+							Url:  "https://example.com",
+						},
+						Concurrency: Concurrency{
+							CancelInProgress: "${{ github.ref != 'refs/heads/main' }}",
+						},
+						Strategy: Strategy{
+							Matrix: Matrix{
+								Expression: "${{ fromJSON(needs.example.outputs.matrix) }}",
+							},
+						},
+						Container: Container{
+							Image:   "node:18",
+							Options: "--cpus 1",
+						},
+						RunsOn: RunsOn{
+							Group:  "ubuntu-runners",
+							Labels: []string{"self-hosted"},
+						},
+					},
+				},
+			},
+			yaml: `on: {}
+jobs:
+  example:
+    name: Example
+    environment: foo-env
+    needs:
+      - other
+    concurrency:
+      group: group B
+      cancel-in-progress: true
+    strategy:
+      matrix:
+        os:
+          - ubuntu-latest
+        node: ${{ fromJSON(inputs.versions) }}
+    container: node:18
+    services:
+      redis:
+        image: redis
+        ports:
+          - 6379/tcp
+    runs-on: ubuntu-latest
+  other:
+    environment:
+      name: bar-env
+      url: https://example.com
+    concurrency:
+      cancel-in-progress: ${{ github.ref != 'refs/heads/main' }}
+    strategy:
+      matrix: ${{ fromJSON(needs.example.outputs.matrix) }}
+    container:
+      image: node:18
+      options: --cpus 1
+    runs-on:
+      group: ubuntu-runners
+      labels:
+        - self-hosted
+`,
+		},
+		"Job with steps": {
+			model: Workflow{
+				Jobs: map[string]Job{
+					"example": {
+						RunsOn: RunsOn{
+							Labels: []string{"self-hosted", "linux"},
+						},
+						Steps: []Step{
+							{
+								Uses: Uses{
+									Name:       "actions/checkout",
+									Ref:        "8f4b7f84864484a7bf31766abe9204da3cbe65b3",
+									Annotation: "v4.2.0",
+								},
+								With: map[string]Value{
+									"persist-credentials": {Kind: ValueBool, Raw: "false"},
+									"fetch-depth":         {Kind: ValueInt, Raw: "1"},
+									"ref":                 {Kind: ValueString, Raw: "1"},
+								},
+							},
+							{
+								Run: "echo foo\necho bar\n",
+							},
+						},
+					},
+				},
+			},
+			yaml: `on: {}
+jobs:
+  example:
+    runs-on:
+      - self-hosted
+      - linux
+    steps:
+      - uses: actions/checkout@8f4b7f84864484a7bf31766abe9204da3cbe65b3 # v4.2.0
+        with:
+          fetch-depth: 1
+          persist-credentials: false
+          ref: "1"
+      - run: |
+          echo foo
+          echo bar
+`,
+		},
+		"Job with 'uses:'": {
+			model: Workflow{
+				Jobs: map[string]Job{
+					"inherit": {
+						Uses: Uses{
+							Name: "./.github/workflows/called-workflow.yml",
+						},
+						Secrets: Secrets{
+							Inherit: true,
+						},
+					},
+					"explicit": {
+						Uses: Uses{
+							Name: "octo-org/example-repo/.github/workflows/called-workflow.yml",
+							Ref:  "main",
+						},
+						Secrets: Secrets{
+							Values: map[string]string{
+								"token": "${{ secrets.TOKEN }}",
+							},
+						},
+					},
+				},
+			},
+			yaml: `on: {}
+jobs:
+  explicit:
+    uses: octo-org/example-repo/.github/workflows/called-workflow.yml@main
+    secrets:
+      token: ${{ secrets.TOKEN }}
+  inherit:
+    uses: ./.github/workflows/called-workflow.yml
+    secrets: inherit
+`,
+		},
+		"Workflow with only some permissions": {
+			model: Workflow{
+				Permissions: Permissions{
+					Mode: PermissionsMapping,
+
+					Actions:        "read",
+					Attestations:   "none",
+					Checks:         "write",
+					Contents:       "none",
+					Deployments:    "none",
+					Discussions:    "none",
+					IdToken:        "none",
+					Issues:         "none",
+					Models:         "none",
+					Packages:       "none",
+					Pages:          "none",
+					PullRequests:   "none",
+					SecurityEvents: "none",
+					Statuses:       "none",
+				},
+				Jobs: map[string]Job{},
+			},
+			yaml: `on: {}
+permissions:
+  actions: read
+  checks: write
+jobs: {}
+`,
+		},
+		"Workflow with 'permissions: read-all'": {
+			model: Workflow{
+				Permissions: Permissions{
+					Mode: PermissionsReadAll,
 
-	for name, tt := range errCases {
+					Actions:        "read",
+					Attestations:   "read",
+					Checks:         "read",
+					Contents:       "read",
+					Deployments:    "read",
+					Discussions:    "read",
+					IdToken:        "read",
+					Issues:         "read",
+					Models:         "read",
+					Packages:       "read",
+					Pages:          "read",
+					PullRequests:   "read",
+					SecurityEvents: "read",
+					Statuses:       "read",
+				},
+				Jobs: map[string]Job{},
+			},
+			yaml: `on: {}
+permissions: read-all
+jobs: {}
+`,
+		},
+		"Workflow with 'permissions: {}'": {
+			model: Workflow{
+				Permissions: Permissions{
+					Mode: PermissionsMapping,
+
+					Actions:        "none",
+					Attestations:   "none",
+					Checks:         "none",
+					Contents:       "none",
+					Deployments:    "none",
+					Discussions:    "none",
+					IdToken:        "none",
+					Issues:         "none",
+					Models:         "none",
+					Packages:       "none",
+					Pages:          "none",
+					PullRequests:   "none",
+					SecurityEvents: "none",
+					Statuses:       "none",
+				},
+				Jobs: map[string]Job{},
+			},
+			yaml: `on: {}
+permissions: {}
+jobs: {}
+`,
+		},
+		"Workflow with every permission set to read": {
+			model: Workflow{
+				Permissions: Permissions{
+					Mode: PermissionsMapping,
+
+					Actions:        "read",
+					Attestations:   "read",
+					Checks:         "read",
+					Contents:       "read",
+					Deployments:    "read",
+					Discussions:    "read",
+					IdToken:        "read",
+					Issues:         "read",
+					Models:         "read",
+					Packages:       "read",
+					Pages:          "read",
+					PullRequests:   "read",
+					SecurityEvents: "read",
+					Statuses:       "read",
+				},
+				Jobs: map[string]Job{},
+			},
+			yaml: `on: {}
+permissions:
+  actions: read
+  attestations: read
+  checks: read
+  contents: read
+  deployments: read
+  discussions: read
+  id-token: read
+  issues: read
+  models: read
+  packages: read
+  pages: read
+  pull-requests: read
+  security-events: read
+  statuses: read
+jobs: {}
+`,
+		},
+		"Strategy with fail-fast unset": {
+			model: Workflow{
+				Jobs: map[string]Job{
+					"example": {
+						Strategy: Strategy{
+							MaxParallel: 2,
+						},
+					},
+				},
+			},
+			yaml: `on: {}
+jobs:
+  example:
+    strategy:
+      max-parallel: 2
+`,
+		},
+		"Strategy with fail-fast disabled": {
+			model: Workflow{
+				Jobs: map[string]Job{
+					"example": {
+						Strategy: Strategy{
+							FailFast:    ptr(false),
+							MaxParallel: 2,
+						},
+					},
+				},
+			},
+			yaml: `on: {}
+jobs:
+  example:
+    strategy:
+      fail-fast: false
+      max-parallel: 2
+`,
+		},
+	}
+
+	for name, tt := range testCases {
 		t.Run(name, func(t *testing.T) {
-			if err := yaml.Unmarshal([]byte(tt.yaml), &tt.model); err == nil {
-				t.Error("Want an error, got none")
+			got, err := tt.model.Bytes()
+			if err != nil {
+				t.Fatalf("Want no error, got %#v", err)
+			}
+
+			if got, want := string(got), tt.yaml; got != want {
+				t.Errorf("Unexpected YAML\ngot:\n%s\nwant:\n%s", got, want)
+			}
+
+			workflow, err := ParseWorkflow(got)
+			if err != nil {
+				t.Fatalf("Want no error on parse, got %#v", err)
+			}
+
+			checkWorkflow(t, &workflow, &tt.model)
+		})
+	}
+}
+
+func TestWorkflowBytesChangedPermissions(t *testing.T) {
+	type TestCase struct {
+		yaml   string
+		change func(p *Permissions)
+		want   string
+	}
+
+	testCases := map[string]TestCase{
+		"Read all, unchanged": {
+			yaml:   `permissions: read-all`,
+			change: func(p *Permissions) {},
+			want: `on: {}
+permissions: read-all
+jobs: {}
+`,
+		},
+		"Read all, one scope changed": {
+			yaml: `permissions: read-all`,
+			change: func(p *Permissions) {
+				p.Contents = "write"
+			},
+			want: `on: {}
+permissions:
+  actions: read
+  attestations: read
+  checks: read
+  contents: write
+  deployments: read
+  discussions: read
+  id-token: read
+  issues: read
+  models: read
+  packages: read
+  pages: read
+  pull-requests: read
+  security-events: read
+  statuses: read
+jobs: {}
+`,
+		},
+		"Write all, every scope changed": {
+			yaml: `permissions: write-all`,
+			change: func(p *Permissions) {
+				for _, scope := range p.scopes() {
+					*scope.value = "read"
+				}
+			},
+			want: `on: {}
+permissions: read-all
+jobs: {}
+`,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			workflow, err := ParseWorkflow([]byte(tt.yaml))
+			if err != nil {
+				t.Fatalf("Want no error, got %#v", err)
+			}
+
+			tt.change(&workflow.Permissions)
+
+			got, err := workflow.Bytes()
+			if err != nil {
+				t.Fatalf("Want no error, got %#v", err)
+			}
+
+			if got, want := string(got), tt.want; got != want {
+				t.Errorf("Unexpected YAML\ngot:\n%s\nwant:\n%s", got, want)
 			}
 		})
 	}
@@ -1322,6 +2805,7 @@ func checkWorkflow(t *testing.T, got, want *Workflow) {
 		t.Errorf("Unexpected workflow run-name (got %q, want %q)", got, want)
 	}
 
+	checkOn(t, &got.On, &want.On)
 	checkConcurrency(t, &got.Concurrency, &want.Concurrency)
 	checkDefaults(t, &got.Defaults, &want.Defaults)
 	checkMap(t, got.Env, want.Env)
@@ -1383,20 +2867,20 @@ func checkJob(t *testing.T, got, want *Job) {
 	checkEnvironment(t, &got.Environment, &want.Environment)
 	checkMap(t, got.Outputs, want.Outputs)
 	checkPermissions(t, &got.Permissions, &want.Permissions)
+	checkService(t, "container", (*Service)(&got.Container), (*Service)(&want.Container))
 	checkServices(t, got.Services, want.Services)
 	checkStrategy(t, &got.Strategy, &want.Strategy)
 
 	/* step-based job */
 
+	checkRunsOn(t, &got.RunsOn, &want.RunsOn)
 	checkSteps(t, got.Steps, want.Steps)
 
 	/* uses-based job */
 
-	if got, want := got.Uses, want.Uses; got != want {
-		t.Errorf("Unexpected job.uses (got %q, want %q)", got, want)
-	}
-
-	checkMap(t, got.With, want.With)
+	checkUses(t, &got.Uses, &want.Uses)
+	checkWith(t, got.With, want.With)
+	checkSecrets(t, &got.Secrets, &want.Secrets)
 }
 
 func checkConcurrency(t *testing.T, got, want *Concurrency) {
@@ -1438,6 +2922,10 @@ func checkEnvironment(t *testing.T, got, want *Environment) {
 func checkPermissions(t *testing.T, got, want *Permissions) {
 	t.Helper()
 
+	if got, want := got.Mode, want.Mode; got != want {
+		t.Errorf("Unexpected permissions mode (got %d, want %d)", got, want)
+	}
+
 	if got, want := got.Actions, want.Actions; got != want {
 		t.Errorf("Unexpected permission for 'actions' (got %q, want %q)", got, want)
 	}
@@ -1495,6 +2983,28 @@ func checkPermissions(t *testing.T, got, want *Permissions) {
 	}
 }
 
+func checkRunsOn(t *testing.T, got, want *RunsOn) {
+	t.Helper()
+
+	if got, want := got.Group, want.Group; got != want {
+		t.Errorf("Unexpected runs-on.group (got %q, want %q)", got, want)
+	}
+
+	if got, want := got.Labels, want.Labels; !slices.Equal(got, want) {
+		t.Errorf("Unexpected runs-on.labels (got %v, want %v)", got, want)
+	}
+}
+
+func checkSecrets(t *testing.T, got, want *Secrets) {
+	t.Helper()
+
+	if got, want := got.Inherit, want.Inherit; got != want {
+		t.Errorf("Unexpected secrets inherit (got %t, want %t)", got, want)
+	}
+
+	checkMap(t, got.Values, want.Values)
+}
+
 func checkServices(t *testing.T, got, want map[string]Service) {
 	t.Helper()
 
@@ -1510,46 +3020,102 @@ func checkServices(t *testing.T, got, want map[string]Service) {
 			continue
 		}
 
-		if got, want := got.Image, want.Image; got != want {
-			t.Errorf("Unexpected image for service %q (got %q, want %q)", name, got, want)
-		}
+		checkService(t, fmt.Sprintf("service %q", name), &got, &want)
+	}
 
-		if got, want := got.Credentials.Username, want.Credentials.Username; got != want {
-			t.Errorf("Unexpected credentials.username for service %q (got %q, want %q)", name, got, want)
+	for name := range want {
+		if _, ok := got[name]; !ok {
+			t.Errorf("Want service named %q but it is not present", name)
 		}
+	}
+}
 
-		if got, want := got.Credentials.Password, want.Credentials.Password; got != want {
-			t.Errorf("Unexpected credentials.password for service %q (got %q, want %q)", name, got, want)
-		}
+func checkService(t *testing.T, what string, got, want *Service) {
+	t.Helper()
 
-		if got, want := got.Ports, want.Ports; !slices.Equal(got, want) {
-			t.Errorf("Unexpected ports for service %q (got %v, want %v)", name, got, want)
-		}
+	if got, want := got.Image, want.Image; got != want {
+		t.Errorf("Unexpected image for %s (got %q, want %q)", what, got, want)
+	}
 
-		if got, want := got.Volumes, want.Volumes; !slices.Equal(got, want) {
-			t.Errorf("Unexpected volumes for service %q (got %v, want %v)", name, got, want)
-		}
+	if got, want := got.Credentials.Username, want.Credentials.Username; got != want {
+		t.Errorf("Unexpected credentials.username for %s (got %q, want %q)", what, got, want)
+	}
 
-		if got, want := got.Options, want.Options; got != want {
-			t.Errorf("Unexpected options for service %q (got %q, want %q)", name, got, want)
-		}
+	if got, want := got.Credentials.Password, want.Credentials.Password; got != want {
+		t.Errorf("Unexpected credentials.password for %s (got %q, want %q)", what, got, want)
+	}
 
-		checkMap(t, got.Env, want.Env)
+	if got, want := got.Ports, want.Ports; !slices.Equal(got, want) {
+		t.Errorf("Unexpected ports for %s (got %v, want %v)", what, got, want)
 	}
 
-	for name := range want {
-		if _, ok := got[name]; !ok {
-			t.Errorf("Want service named %q but it is not present", name)
-		}
+	if got, want := got.Volumes, want.Volumes; !slices.Equal(got, want) {
+		t.Errorf("Unexpected volumes for %s (got %v, want %v)", what, got, want)
+	}
+
+	if got, want := got.Options, want.Options; got != want {
+		t.Errorf("Unexpected options for %s (got %q, want %q)", what, got, want)
 	}
+
+	checkMap(t, got.Env, want.Env)
 }
 
 func checkStrategy(t *testing.T, got, want *Strategy) {
 	t.Helper()
 
-	for _, got := range got.Matrix {
+	checkMatrix(t, &got.Matrix, &want.Matrix)
+
+	switch got, want := got.FailFast, want.FailFast; {
+	case got == nil && want == nil:
+	case got == nil || want == nil:
+		t.Errorf("Unexpected fail-fast for strategy (got %v, want %v)", got, want)
+	case *got != *want:
+		t.Errorf("Unexpected fail-fast for strategy (got %t, want %t)", *got, *want)
+	}
+
+	if got, want := got.MaxParallel, want.MaxParallel; got != want {
+		t.Errorf("Unexpected max-parallel for strategy (got %d, want %d)", got, want)
+	}
+}
+
+func checkMatrix(t *testing.T, got, want *Matrix) {
+	t.Helper()
+
+	if got, want := got.Expression, want.Expression; got != want {
+		t.Errorf("Unexpected matrix expression (got %q, want %q)", got, want)
+	}
+
+	if got, want := got.Axes, want.Axes; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected matrix axes (got %v, want %v)", got, want)
+	}
+
+	if got, want := got.Include, want.Include; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected matrix include (got %v, want %v)", got, want)
+	}
+
+	if got, want := got.IncludeExpression, want.IncludeExpression; got != want {
+		t.Errorf("Unexpected matrix include expression (got %q, want %q)", got, want)
+	}
+
+	if got, want := got.Exclude, want.Exclude; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected matrix exclude (got %v, want %v)", got, want)
+	}
+
+	if got, want := got.ExcludeExpression, want.ExcludeExpression; got != want {
+		t.Errorf("Unexpected matrix exclude expression (got %q, want %q)", got, want)
+	}
+}
+
+func checkCombinations(t *testing.T, got, want []map[string]any) {
+	t.Helper()
+
+	if got, want := len(got), len(want); got != want {
+		t.Errorf("Unexpected number of entries in matrix (got %d, want %d)", got, want)
+	}
+
+	for _, got := range got {
 		found := false
-		for _, want := range want.Matrix {
+		for _, want := range want {
 			if reflect.DeepEqual(got, want) {
 				found = true
 			}
@@ -1560,9 +3126,9 @@ func checkStrategy(t *testing.T, got, want *Strategy) {
 		}
 	}
 
-	for _, want := range want.Matrix {
+	for _, want := range want {
 		found := false
-		for _, got := range got.Matrix {
+		for _, got := range got {
 			if reflect.DeepEqual(got, want) {
 				found = true
 			}
@@ -1572,12 +3138,177 @@ func checkStrategy(t *testing.T, got, want *Strategy) {
 			t.Errorf("Missing entry in matrix: %v", want)
 		}
 	}
+}
+
+func TestWorkflowExtra(t *testing.T) {
+	yaml := `name: Example
+on:
+  push:
+    branches:
+      - main
+    future-filter: true
+  future_event:
+    types: [created]
+future-key:
+  nested: value
+jobs:
+  example:
+    runs-on:
+      group: large
+      future-key: 1
+    concurrency:
+      group: example
+      future-key: 2
+    environment:
+      name: production
+      future-key: 3
+    container:
+      image: node:20
+      future-key: 4
+    strategy:
+      max-parallel: 2
+      future-key: 5
+    permissions:
+      contents: read
+      repository-projects: write
+    snapshot: my-image
+    steps:
+      - run: echo 'Hello world!'
+        future-key: [a, b]
+`
+
+	workflow, err := ParseWorkflow([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Want no error, got %#v", err)
+	}
+
+	job := workflow.Jobs["example"]
 
-	if got, want := got.FailFast, want.FailFast; got != want {
-		t.Errorf("Unexpected fail-fast for strategy (got %t, want %t)", got, want)
+	type TestCase struct {
+		extra Extra
+		key   string
+		want  string
 	}
 
-	if got, want := got.MaxParallel, want.MaxParallel; got != want {
-		t.Errorf("Unexpected max-parallel for strategy (got %d, want %d)", got, want)
+	testCases := map[string]TestCase{
+		"workflow": {
+			extra: workflow.Extra,
+			key:   "future-key",
+			want:  "nested: value\n",
+		},
+		"event": {
+			extra: workflow.On.Push.Extra,
+			key:   "future-filter",
+			want:  "true\n",
+		},
+		"unknown event": {
+			extra: workflow.On.Extra,
+			key:   "future_event",
+			want:  "types: [created]\n",
+		},
+		"job": {
+			extra: job.Extra,
+			key:   "snapshot",
+			want:  "my-image\n",
+		},
+		"runs-on": {
+			extra: job.RunsOn.Extra,
+			key:   "future-key",
+			want:  "1\n",
+		},
+		"concurrency": {
+			extra: job.Concurrency.Extra,
+			key:   "future-key",
+			want:  "2\n",
+		},
+		"environment": {
+			extra: job.Environment.Extra,
+			key:   "future-key",
+			want:  "3\n",
+		},
+		"container": {
+			extra: job.Container.Extra,
+			key:   "future-key",
+			want:  "4\n",
+		},
+		"strategy": {
+			extra: job.Strategy.Extra,
+			key:   "future-key",
+			want:  "5\n",
+		},
+		"permissions": {
+			extra: job.Permissions.Extra,
+			key:   "repository-projects",
+			want:  "write\n",
+		},
+		"step": {
+			extra: job.Steps[0].Extra,
+			key:   "future-key",
+			want:  "[a, b]\n",
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			v, ok := tt.extra[tt.key]
+			if !ok {
+				t.Fatalf("Missing extra key %q", tt.key)
+			}
+
+			got, err := marshal(&v)
+			if err != nil {
+				t.Fatalf("Want no error, got %#v", err)
+			}
+
+			if got, want := string(got), tt.want; got != want {
+				t.Errorf("Unexpected value (got %q, want %q)", got, want)
+			}
+		})
 	}
+
+	t.Run("marshal", func(t *testing.T) {
+		got, err := workflow.Bytes()
+		if err != nil {
+			t.Fatalf("Want no error, got %#v", err)
+		}
+
+		want := `name: Example
+on:
+  push:
+    branches:
+      - main
+    future-filter: true
+  future_event:
+    types: [created]
+jobs:
+  example:
+    environment:
+      name: production
+      future-key: 3
+    concurrency:
+      group: example
+      future-key: 2
+    strategy:
+      max-parallel: 2
+      future-key: 5
+    container:
+      image: node:20
+      future-key: 4
+    permissions:
+      contents: read
+      repository-projects: write
+    runs-on:
+      group: large
+      future-key: 1
+    steps:
+      - run: echo 'Hello world!'
+        future-key: [a, b]
+    snapshot: my-image
+future-key:
+  nested: value
+`
+		if got := string(got); got != want {
+			t.Errorf("Unexpected YAML\ngot:\n%s\nwant:\n%s", got, want)
+		}
+	})
 }